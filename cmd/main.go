@@ -13,9 +13,15 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/schema"
 	"github.com/imroc/req/v3"
+	"github.com/olajoe/forecast_weather_api/internal/auth"
+	"github.com/olajoe/forecast_weather_api/internal/breaker"
+	"github.com/olajoe/forecast_weather_api/internal/cache"
 	"github.com/olajoe/forecast_weather_api/internal/config"
-	"github.com/olajoe/forecast_weather_api/internal/middlewares"
+	"github.com/olajoe/forecast_weather_api/internal/health"
+	"github.com/olajoe/forecast_weather_api/internal/metrics"
+	"github.com/olajoe/forecast_weather_api/internal/ratelimit"
 	v1 "github.com/olajoe/forecast_weather_api/internal/routes/v1"
+	"github.com/olajoe/forecast_weather_api/internal/tracing"
 	"github.com/olajoe/forecast_weather_api/internal/validator"
 	"github.com/olajoe/forecast_weather_api/internal/weather"
 
@@ -32,37 +38,123 @@ func main() {
 	rootCtx, rootCancel := context.WithCancel(context.Background())
 	defer rootCancel()
 
+	shutdownTracing, err := tracing.Setup(rootCtx, cfg)
+	if err != nil {
+		logger.Fatal().Msgf("Cannot set up tracing: %s", err.Error())
+	}
+
 	r := mux.NewRouter()
 	corsHandler := gorillaHandlers.CORS(
 		gorillaHandlers.AllowedOrigins(strings.Split(cfg.Cors.Origins, ",")),
 		gorillaHandlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		gorillaHandlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Origin", "x-api-key", "x-secret-key"}),
+		gorillaHandlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Origin", "x-api-key", "x-secret-key", logging.HeaderCorrelationID}),
+		gorillaHandlers.ExposedHeaders([]string{https.HeaderRateLimitLimit, https.HeaderRateLimitRemaining, https.HeaderRateLimitReset, https.HeaderRateLimitPolicy, https.HeaderRetryAfter, logging.HeaderCorrelationID}),
 	)
 
-	loggerMiddleware := middlewares.NewLoggerMiddleware(logger)
+	loggingMiddleware := logging.NewMiddleware(logger, cfg.AccessLog)
+	metricsMiddleware := metrics.NewMiddleware()
 
-	r.Use(loggerMiddleware.LogResponse)
-	r.HandleFunc("/healthz", https.HealthCheckHandler).Methods(http.MethodGet)
+	r.Use(tracing.NewMiddleware(), loggingMiddleware, metricsMiddleware)
+	// Unmatched requests skip the router middlewares, wrap them so that they
+	// are logged and get a correlation ID too.
+	r.NotFoundHandler = loggingMiddleware(metricsMiddleware(http.NotFoundHandler()))
+	r.MethodNotAllowedHandler = loggingMiddleware(metricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})))
 
 	v1Router := r.PathPrefix("/v1").Subrouter()
-	v1Router.Use()
+	switch cfg.Auth.Mode {
+	case auth.ModeAPIKey:
+		apiKeyStore, err := auth.NewAPIKeyStore(cfg)
+		if err != nil {
+			logger.Fatal().Msgf("Cannot load API keys: %s", err.Error())
+		}
+
+		v1Router.Use(https.NewMiddlewareAPIKeyAuth(apiKeyStore))
+	case auth.ModeJWT:
+		jwtVerifier, err := auth.NewJWTVerifier(rootCtx, cfg)
+		if err != nil {
+			logger.Fatal().Msgf("Cannot create JWT verifier: %s", err.Error())
+		}
+
+		v1Router.Use(https.NewMiddlewareJWTAuth(jwtVerifier))
+	case auth.ModeNone, "":
+	default:
+		logger.Fatal().Msgf("Unknown auth mode %s", cfg.Auth.Mode)
+	}
+	if cfg.ClientRateLimit.Enabled {
+		rateLimitStore, err := ratelimit.NewStore(cfg)
+		if err != nil {
+			logger.Fatal().Msgf("Cannot create rate limit store: %s", err.Error())
+		}
+
+		v1Router.Use(https.NewMiddlewareRateLimit(rateLimitStore, cfg.ClientRateLimit))
+	}
+	// Rejected after the rate limiter so that failed attempts are counted too
+	if cfg.Auth.Mode == auth.ModeAPIKey || cfg.Auth.Mode == auth.ModeJWT {
+		v1Router.Use(https.NewMiddlewareRequireAuth())
+	}
 
 	// dependency
-	client := req.C().SetTimeout(5 * time.Second)
+	readiness := health.NewReadiness(cfg.Readiness.Timeout, cfg.Readiness.CacheTTL)
+	client := tracing.InstrumentClient(req.C().SetTimeout(cfg.Tmd.Timeout))
+	if cfg.Tmd.RateLimit.Rate > 0 {
+		tmdLimiter := ratelimit.New(cfg.Tmd.RateLimit.Rate, cfg.Tmd.RateLimit.Burst, cfg.Tmd.RateLimit.MaxWait)
+		readiness.RegisterDetail("tmdRateLimiter", func() any { return tmdLimiter.Stats() })
+		metrics.RegisterRateLimiter(tmdLimiter)
+
+		client = weather.NewRateLimitedClient(client, tmdLimiter)
+	}
+
 	_validator := validator.NewValidator()
+	translator, err := validator.NewTranslator(_validator)
+	if err != nil {
+		logger.Fatal().Msgf("Cannot register validation translations: %s", err.Error())
+	}
 	schemaDecoder := schema.NewDecoder()
 	schemaDecoder.IgnoreUnknownKeys(true)
 
 	// repository
 	weatherRepo := weather.NewWeatherRepository(client, cfg)
+	readiness.Register("config", health.NewConfigCheck(cfg))
+	if cfg.Readiness.TMDProbe {
+		readiness.Register("tmd", health.NewTMDCheck(cfg))
+	}
+	if cfg.Tmd.Breaker.FailureThreshold > 0 {
+		tmdBreaker := breaker.New(cfg.Tmd.Breaker.FailureThreshold, cfg.Tmd.Breaker.CoolDown, weather.IsUpstreamFailure, weather.IsInconclusive)
+		readiness.RegisterDetail("tmdCircuitBreaker", func() any { return tmdBreaker.State().String() })
+		metrics.RegisterCircuitBreaker(tmdBreaker)
+
+		weatherRepo = weather.NewCircuitBreakerWeatherRepository(weatherRepo, tmdBreaker)
+	}
+	if cfg.Cache.TTL > 0 {
+		weatherCache, err := cache.New(cfg)
+		if err != nil {
+			logger.Fatal().Msgf("Cannot create cache: %s", err.Error())
+		}
+
+		readiness.Register("cache", weatherCache.Ping)
+
+		cachedWeatherRepo := weather.NewCachedWeatherRepository(weatherRepo, weatherCache, cfg)
+		metrics.RegisterCache(func() (int64, int64, int64) {
+			stats := cachedWeatherRepo.Stats()
+			return stats.Hits, stats.Misses, stats.StaleHits
+		})
+
+		weatherRepo = cachedWeatherRepo
+	}
 
 	// usecase
-	weatherUsecase := weather.NewWeatherUsecase(weatherRepo)
+	weatherUsecase := weather.NewWeatherUsecase(weatherRepo, cfg)
 
 	// handler
-	weatherHandler := weather.NewWeatherHandler(_validator, schemaDecoder, weatherUsecase)
+	weatherHandler := weather.NewWeatherHandler(_validator, translator, schemaDecoder, weatherUsecase, cfg)
 
-	v1.RegisterRoutes(v1Router, weatherHandler)
+	r.HandleFunc("/healthz", https.NewHealthCheckHandler(readiness)).Methods(http.MethodGet)
+	r.HandleFunc("/livez", https.NewLivenessHandler()).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", https.NewReadinessHandler(readiness)).Methods(http.MethodGet)
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	v1.RegisterRoutes(v1Router, cfg, weatherHandler)
 
 	// Create signal channel
 	stop := make(chan os.Signal, 1)
@@ -93,6 +185,13 @@ func main() {
 		logger.Info().Msg("Server shutdown completed successfully")
 	}
 
+	// Flush the remaining spans once no request is in flight
+	tracingCtx, tracingCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer tracingCancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		logger.Warn().Msgf("Cannot flush traces: %s", err.Error())
+	}
+
 	logger.Info().Msg("Server exiting")
 
 }
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olajoe/forecast_weather_api/internal/config"
+)
+
+// APIKey is a known key stored as the hex SHA-256 of its value, so a leaked
+// key list does not leak the keys themselves.
+type APIKey struct {
+	Name   string   `json:"name"`
+	Hash   string   `json:"hash"`
+	Scopes []string `json:"scopes"`
+}
+
+// APIKeyStore resolves API keys to the identity they belong to.
+type APIKeyStore struct {
+	identities map[string]Identity // by hash
+}
+
+// NewAPIKeyStore loads the keys of cfg.Auth.APIKeysFile and cfg.Auth.APIKeys.
+func NewAPIKeyStore(cfg *config.Configuration) (*APIKeyStore, error) {
+	var keys []APIKey
+
+	if cfg.Auth.APIKeysFile != "" {
+		content, err := os.ReadFile(cfg.Auth.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(content, &keys); err != nil {
+			return nil, fmt.Errorf("invalid API keys file %s: %w", cfg.Auth.APIKeysFile, err)
+		}
+	}
+
+	envKeys, err := parseAPIKeys(cfg.Auth.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	keys = append(keys, envKeys...)
+
+	identities := make(map[string]Identity, len(keys))
+	for _, key := range keys {
+		hash := strings.ToLower(key.Hash)
+		if key.Name == "" || len(hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("API key %q needs a name and a hex SHA-256 hash", key.Name)
+		}
+
+		identities[hash] = Identity{Name: key.Name, Scopes: key.Scopes}
+	}
+
+	return &APIKeyStore{identities: identities}, nil
+}
+
+// Authenticate returns the identity of apiKey.
+func (s *APIKeyStore) Authenticate(apiKey string) (Identity, error) {
+	if apiKey == "" {
+		return Identity{}, ErrMissingCredentials
+	}
+
+	hash := sha256.Sum256([]byte(apiKey))
+	identity, ok := s.identities[hex.EncodeToString(hash[:])]
+	if !ok {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	return identity, nil
+}
+
+// parseAPIKeys reads comma separated name:hash:scopes entries, where scopes
+// are separated by spaces, e.g. "logistics:9f86...:forecast:read forecast:batch".
+func parseAPIKeys(value string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("API key %q is not name:hash:scopes", entry)
+		}
+
+		key := APIKey{Name: parts[0], Hash: parts[1]}
+		if len(parts) == 3 {
+			key.Scopes = strings.Fields(parts[2])
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+const (
+	ModeNone   = "none"
+	ModeAPIKey = "api_key"
+	ModeJWT    = "jwt"
+)
+
+// Scopes required by the /v1 routes.
+const (
+	ScopeForecastRead  = "forecast:read"
+	ScopeForecastBatch = "forecast:batch"
+)
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	Name   string
+	Scopes []string
+}
+
+func (i Identity) HasScope(scope string) bool {
+	return slices.Contains(i.Scopes, scope)
+}
+
+type identityContextKey struct{}
+
+func NewContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// FromContext returns the caller set by the auth middleware, ok is false for
+// anonymous requests.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey{}).(Identity)
+	return identity, ok
+}
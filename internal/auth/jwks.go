@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
+	"golang.org/x/sync/singleflight"
+)
+
+// minJWKSRefetchInterval bounds how often an unknown key id or a failed fetch
+// triggers a refetch.
+const minJWKSRefetchInterval = time.Minute
+
+// jwksFetchTimeout bounds a fetch, which is shared by every caller waiting for
+// it and so is not cancelled with the request that started it.
+const jwksFetchTimeout = 10 * time.Second
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// jwks holds the public keys of a JSON Web Key Set by key id. A set read from
+// a file never changes, a set fetched from a URL is fetched again once
+// refreshInterval has passed or when a token is signed with an unknown key.
+// Concurrent callers share one fetch, the lock is not held while it runs.
+type jwks struct {
+	mu              sync.Mutex
+	keys            map[string]any
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	fetchedAt       time.Time
+	failedAt        time.Time
+	fetchGroup      singleflight.Group
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func newFileJWKS(path string) (*jwks, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := parseJWKS(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWKS file %s: %w", path, err)
+	}
+
+	return &jwks{keys: keys}, nil
+}
+
+func newURLJWKS(ctx context.Context, url string, refreshInterval time.Duration) (*jwks, error) {
+	set := &jwks{
+		url:             url,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		refreshInterval: refreshInterval,
+	}
+
+	keys, err := set.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	set.keys = keys
+	set.fetchedAt = time.Now()
+
+	return set, nil
+}
+
+// key returns the public key for kid. An empty kid matches a set of one key.
+func (s *jwks) key(ctx context.Context, kid string) (any, error) {
+	if s.needsRefresh(kid) {
+		s.refresh(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errUnknownKey, kid)
+	}
+
+	return key, nil
+}
+
+func (s *jwks) needsRefresh(kid string) bool {
+	if s.url == "" {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.failedAt) < minJWKSRefetchInterval {
+		return false
+	}
+
+	sinceFetch := time.Since(s.fetchedAt)
+	_, known := s.keys[kid]
+	if kid == "" && len(s.keys) == 1 {
+		known = true
+	}
+
+	return sinceFetch >= s.refreshInterval || (!known && sinceFetch >= minJWKSRefetchInterval)
+}
+
+// refresh waits for the shared fetch until ctx is done, the previous keys are
+// kept when it fails.
+func (s *jwks) refresh(ctx context.Context) {
+	result := s.fetchGroup.DoChan("", func() (any, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jwksFetchTimeout)
+		defer cancel()
+
+		keys, err := s.fetch(fetchCtx)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if err != nil {
+			s.failedAt = time.Now()
+			logging.Ctx(fetchCtx).Warn().Err(err).Str("url", s.url).Msg("cannot refresh JWKS, keeping the previous keys")
+			return nil, err
+		}
+		s.keys = keys
+		s.fetchedAt = time.Now()
+
+		return nil, nil
+	})
+
+	select {
+	case <-result:
+	case <-ctx.Done():
+	}
+}
+
+func (s *jwks) fetch(ctx context.Context) (map[string]any, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS %s responded with status %d", s.url, response.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := parseJWKS(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWKS %s: %w", s.url, err)
+	}
+
+	return keys, nil
+}
+
+// parseJWKS reads the RSA, EC and Ed25519 signing keys of a key set and skips
+// keys of other types or uses.
+func parseJWKS(content []byte) (map[string]any, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]any{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 {
+		return nil, fmt.Errorf("invalid key parameter %q", value)
+	}
+
+	return new(big.Int).SetBytes(decoded), nil
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestJWKSServer serves the key set of newTestKeys through handle, which
+// answers with the set when it returns true.
+func newTestJWKSServer(t *testing.T, handle func(w http.ResponseWriter) bool) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	_, path := newTestKeys(t)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if fetches.Add(1) > 1 && !handle(w) {
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &fetches
+}
+
+func (s *jwks) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fetchedAt = s.fetchedAt.Add(-2 * time.Hour)
+}
+
+func (s *jwks) lastFetch() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fetchedAt
+}
+
+func TestJWKSRefreshDoesNotBlockCallers(t *testing.T) {
+	release := make(chan struct{})
+	server, fetches := newTestJWKSServer(t, func(http.ResponseWriter) bool {
+		<-release
+		return true
+	})
+	defer func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	}()
+
+	set, err := newURLJWKS(context.Background(), server.URL, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set.expire()
+	expiredAt := set.lastFetch()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			// the previous keys are used while the refresh is slow
+			if _, err := set.key(ctx, "rsa"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	close(release)
+	for deadline := time.Now().Add(5 * time.Second); set.lastFetch().Equal(expiredAt); {
+		if time.Now().After(deadline) {
+			t.Fatal("the refresh did not finish after its callers gave up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("fetches = %d, want the callers to share one refresh", got)
+	}
+}
+
+func TestJWKSFailedRefreshKeepsKeys(t *testing.T) {
+	server, fetches := newTestJWKSServer(t, func(w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	})
+
+	set, err := newURLJWKS(context.Background(), server.URL, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set.expire()
+	expiredAt := set.lastFetch()
+
+	for range 3 {
+		if _, err := set.key(context.Background(), "rsa"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !set.lastFetch().Equal(expiredAt) {
+		t.Fatalf("fetchedAt = %s, want it unchanged by a failed fetch", set.lastFetch())
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("fetches = %d, want a failed fetch to be retried after minJWKSRefetchInterval", got)
+	}
+}
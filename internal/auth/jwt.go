@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+)
+
+// JWTVerifier authenticates OIDC issued bearer tokens signed by a key of the
+// configured JWKS and issued by Issuer for Audience.
+type JWTVerifier struct {
+	keys       *jwks
+	parser     *jwt.Parser
+	scopeClaim string
+}
+
+// NewJWTVerifier loads the key set of cfg.Auth.JWT.JWKSFile, or fetches it
+// from cfg.Auth.JWT.JWKSURL.
+func NewJWTVerifier(ctx context.Context, cfg *config.Configuration) (*JWTVerifier, error) {
+	jwtCfg := cfg.Auth.JWT
+	if jwtCfg.Issuer == "" || jwtCfg.Audience == "" {
+		return nil, errors.New("JWT auth needs an issuer and an audience")
+	}
+
+	var keys *jwks
+	var err error
+	switch {
+	case jwtCfg.JWKSFile != "":
+		keys, err = newFileJWKS(jwtCfg.JWKSFile)
+	case jwtCfg.JWKSURL != "":
+		keys, err = newURLJWKS(ctx, jwtCfg.JWKSURL, jwtCfg.JWKSRefreshInterval)
+	default:
+		err = errors.New("JWT auth needs a JWKS file or URL")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTVerifier{
+		keys: keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+			jwt.WithIssuer(jwtCfg.Issuer),
+			jwt.WithAudience(jwtCfg.Audience),
+			jwt.WithExpirationRequired(),
+		),
+		scopeClaim: jwtCfg.ScopeClaim,
+	}, nil
+}
+
+// Authenticate verifies token and returns its subject with the scopes of the
+// scope claim, either a space separated string or a list of strings.
+func (v *JWTVerifier) Authenticate(ctx context.Context, token string) (Identity, error) {
+	if token == "" {
+		return Identity{}, ErrMissingCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.key(ctx, kid)
+	})
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return Identity{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+
+	return Identity{Name: subject, Scopes: scopesFromClaim(claims[v.scopeClaim])}, nil
+}
+
+func scopesFromClaim(claim any) []string {
+	switch value := claim.(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		scopes := make([]string, 0, len(value))
+		for _, item := range value {
+			if scope, ok := item.(string); ok {
+				scopes = append(scopes, scope)
+			}
+		}
+
+		return scopes
+	default:
+		return nil
+	}
+}
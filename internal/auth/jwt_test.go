@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+)
+
+const (
+	testIssuer   = "https://issuer.example"
+	testAudience = "forecast-weather-api"
+)
+
+type testKey struct {
+	kid    string
+	method jwt.SigningMethod
+	signer crypto.Signer
+}
+
+// newTestKeys generates one RSA, EC and Ed25519 key and writes their public
+// keys to a JWKS file.
+func newTestKeys(t *testing.T) ([]testKey, string) {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []testKey{
+		{"rsa", jwt.SigningMethodRS256, rsaKey},
+		{"ec", jwt.SigningMethodES256, ecKey},
+		{"ed", jwt.SigningMethodEdDSA, edKey},
+	}
+
+	encode := base64.RawURLEncoding.EncodeToString
+	set := map[string][]map[string]string{"keys": {
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "use": "sig", "crv": "P-256", "x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "kid": "ed", "use": "sig", "crv": "Ed25519", "x": encode(edKey.Public().(ed25519.PublicKey))},
+	}}
+
+	content, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return keys, path
+}
+
+func newTestVerifier(t *testing.T, jwksFile string) *JWTVerifier {
+	t.Helper()
+
+	cfg := &config.Configuration{Auth: config.AuthConfig{JWT: config.JWTConfig{
+		JWKSFile:   jwksFile,
+		Issuer:     testIssuer,
+		Audience:   testAudience,
+		ScopeClaim: "scope",
+	}}}
+
+	verifier, err := NewJWTVerifier(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return verifier
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "client-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": ScopeForecastRead,
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key crypto.Signer, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func TestJWTVerifierAcceptsEveryKeyType(t *testing.T) {
+	keys, jwksFile := newTestKeys(t)
+	verifier := newTestVerifier(t, jwksFile)
+
+	for _, key := range keys {
+		t.Run(key.kid, func(t *testing.T) {
+			identity, err := verifier.Authenticate(context.Background(), sign(t, key.method, key.kid, key.signer, validClaims()))
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if identity.Name != "client-1" || !identity.HasScope(ScopeForecastRead) {
+				t.Fatalf("identity = %+v", identity)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierRejectsInvalidTokens(t *testing.T) {
+	keys, jwksFile := newTestKeys(t)
+	verifier := newTestVerifier(t, jwksFile)
+	rsaKey, ecKey := keys[0], keys[1]
+
+	withClaim := func(name string, value any) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"missing token", "", ErrMissingCredentials},
+		{"wrong issuer", sign(t, rsaKey.method, rsaKey.kid, rsaKey.signer, withClaim("iss", "https://other.example")), ErrInvalidCredentials},
+		{"wrong audience", sign(t, rsaKey.method, rsaKey.kid, rsaKey.signer, withClaim("aud", "other-api")), ErrInvalidCredentials},
+		{"expired", sign(t, rsaKey.method, rsaKey.kid, rsaKey.signer, withClaim("exp", time.Now().Add(-time.Minute).Unix())), ErrInvalidCredentials},
+		{"missing exp", sign(t, rsaKey.method, rsaKey.kid, rsaKey.signer, withClaim("exp", nil)), ErrInvalidCredentials},
+		{"missing subject", sign(t, rsaKey.method, rsaKey.kid, rsaKey.signer, withClaim("sub", nil)), ErrInvalidCredentials},
+		{"unknown kid", sign(t, rsaKey.method, "unknown", rsaKey.signer, validClaims()), ErrInvalidCredentials},
+		{"alg does not match the key type", sign(t, ecKey.method, rsaKey.kid, ecKey.signer, validClaims()), ErrInvalidCredentials},
+		{"signed by another key", sign(t, ecKey.method, ecKey.kid, mustECKey(t), validClaims()), ErrInvalidCredentials},
+		{"symmetric alg", signHS256(t, rsaKey.kid), ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Authenticate(context.Background(), tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierScopeClaim(t *testing.T) {
+	keys, jwksFile := newTestKeys(t)
+	verifier := newTestVerifier(t, jwksFile)
+	key := keys[0]
+
+	tests := []struct {
+		name  string
+		scope any
+		want  []string
+	}{
+		{"space separated string", "forecast:read forecast:batch", []string{ScopeForecastRead, ScopeForecastBatch}},
+		{"array", []string{ScopeForecastRead, ScopeForecastBatch}, []string{ScopeForecastRead, ScopeForecastBatch}},
+		{"missing", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			delete(claims, "scope")
+			if tt.scope != nil {
+				claims["scope"] = tt.scope
+			}
+
+			identity, err := verifier.Authenticate(context.Background(), sign(t, key.method, key.kid, key.signer, claims))
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if !slices.Equal(identity.Scopes, tt.want) {
+				t.Fatalf("scopes = %v, want %v", identity.Scopes, tt.want)
+			}
+		})
+	}
+}
+
+func mustECKey(t *testing.T) crypto.Signer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func signHS256(t *testing.T, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString([]byte("shared secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
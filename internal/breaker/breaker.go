@@ -0,0 +1,166 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Breaker opens after failureThreshold consecutive failures and rejects calls
+// with ErrOpen until coolDown has passed. It then lets a single trial call
+// through (half-open) which closes the breaker on success or opens it again.
+// Calls that end while the breaker is open, and inconclusive outcomes such as
+// a cancelled call, change nothing.
+type Breaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	coolDown         time.Duration
+	isFailure        func(error) bool
+	isInconclusive   func(error) bool
+	now              func() time.Time
+
+	state    State
+	failures int
+	openedAt time.Time
+	trialing bool
+}
+
+// New creates a closed breaker. isFailure decides which errors count towards
+// the threshold, nil counts every error. isInconclusive decides which errors
+// say nothing about the health of the callee, nil only ignores context.Canceled.
+func New(failureThreshold int, coolDown time.Duration, isFailure func(error) bool, isInconclusive func(error) bool) *Breaker {
+	return newBreaker(failureThreshold, coolDown, isFailure, isInconclusive, time.Now)
+}
+
+func newBreaker(failureThreshold int, coolDown time.Duration, isFailure func(error) bool, isInconclusive func(error) bool, now func() time.Time) *Breaker {
+	if isFailure == nil {
+		isFailure = func(err error) bool { return err != nil }
+	}
+	if isInconclusive == nil {
+		isInconclusive = func(err error) bool { return errors.Is(err, context.Canceled) }
+	}
+
+	return &Breaker{
+		failureThreshold: failureThreshold,
+		coolDown:         coolDown,
+		isFailure:        isFailure,
+		isInconclusive:   isInconclusive,
+		now:              now,
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.currentState()
+}
+
+// Execute runs fn unless the breaker is open and records its outcome.
+func (b *Breaker) Execute(fn func() error) error {
+	trial, err := b.before()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	b.after(err, trial)
+
+	return err
+}
+
+// before reports whether the call is the trial of a half-open breaker.
+func (b *Breaker) before() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState() {
+	case StateOpen:
+		return false, ErrOpen
+	case StateHalfOpen:
+		if b.trialing {
+			return false, ErrOpen
+		}
+		b.state = StateHalfOpen
+		b.trialing = true
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (b *Breaker) after(err error, trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := err != nil && b.isFailure(err)
+	inconclusive := err != nil && !failed && b.isInconclusive(err)
+
+	if trial {
+		b.trialing = false
+		switch {
+		case failed:
+			b.open()
+		case inconclusive:
+			// stay half-open, the next call is a new trial
+		default:
+			b.state = StateClosed
+			b.failures = 0
+		}
+		return
+	}
+
+	// The call started before the breaker opened, its outcome is stale.
+	if b.state != StateClosed || inconclusive {
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.failureThreshold {
+		b.open()
+	}
+}
+
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.failures = 0
+}
+
+// currentState moves an open breaker to half-open once coolDown has passed.
+func (b *Breaker) currentState() State {
+	if b.state == StateOpen && !b.now().Before(b.openedAt.Add(b.coolDown)) {
+		return StateHalfOpen
+	}
+
+	return b.state
+}
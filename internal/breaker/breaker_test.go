@@ -0,0 +1,184 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	errUnavailable = errors.New("unavailable")
+	errNotFound    = errors.New("not found")
+)
+
+type testBreaker struct {
+	*Breaker
+	now time.Time
+}
+
+func newTestBreaker() *testBreaker {
+	tb := &testBreaker{now: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)}
+	isFailure := func(err error) bool { return errors.Is(err, errUnavailable) }
+	tb.Breaker = newBreaker(3, 30*time.Second, isFailure, nil, func() time.Time { return tb.now })
+
+	return tb
+}
+
+func (tb *testBreaker) call(t *testing.T, err error) {
+	t.Helper()
+
+	if got := tb.Execute(func() error { return err }); !errors.Is(got, err) {
+		t.Fatalf("Execute error = %v, want %v", got, err)
+	}
+}
+
+func (tb *testBreaker) assertState(t *testing.T, want State) {
+	t.Helper()
+
+	if got := tb.State(); got != want {
+		t.Fatalf("state = %s, want %s", got, want)
+	}
+}
+
+// open fails the breaker up to its threshold.
+func (tb *testBreaker) open(t *testing.T) {
+	t.Helper()
+
+	for range 3 {
+		tb.call(t, errUnavailable)
+	}
+	tb.assertState(t, StateOpen)
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	tb := newTestBreaker()
+
+	tb.call(t, errUnavailable)
+	tb.call(t, errUnavailable)
+	tb.call(t, nil)
+	tb.call(t, errUnavailable)
+	tb.call(t, errNotFound)
+	tb.call(t, errUnavailable)
+	tb.assertState(t, StateClosed)
+
+	tb.call(t, errUnavailable)
+	tb.call(t, errUnavailable)
+	tb.assertState(t, StateOpen)
+
+	called := false
+	err := tb.Execute(func() error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrOpen) || called {
+		t.Fatalf("Execute error = %v, called = %v, want ErrOpen without calling", err, called)
+	}
+}
+
+func TestBreakerHalfOpenTrial(t *testing.T) {
+	tests := []struct {
+		name      string
+		trialErr  error
+		wantState State
+	}{
+		{"success closes", nil, StateClosed},
+		{"non failure error closes", errNotFound, StateClosed},
+		{"failure opens again", errUnavailable, StateOpen},
+		{"cancelled trial stays half-open", context.Canceled, StateHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := newTestBreaker()
+			tb.open(t)
+
+			tb.now = tb.now.Add(29 * time.Second)
+			tb.assertState(t, StateOpen)
+			tb.now = tb.now.Add(time.Second)
+			tb.assertState(t, StateHalfOpen)
+
+			tb.call(t, tt.trialErr)
+			tb.assertState(t, tt.wantState)
+		})
+	}
+}
+
+func TestBreakerAllowsOneTrialAtATime(t *testing.T) {
+	tb := newTestBreaker()
+	tb.open(t)
+	tb.now = tb.now.Add(30 * time.Second)
+
+	err := tb.Execute(func() error {
+		if err := tb.Execute(func() error { return nil }); !errors.Is(err, ErrOpen) {
+			t.Fatalf("concurrent call error = %v, want ErrOpen during the trial", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tb.assertState(t, StateClosed)
+}
+
+func TestBreakerIgnoresCallsEndingWhileOpen(t *testing.T) {
+	tb := newTestBreaker()
+
+	// calls that started while the breaker was closed and fail after it opened
+	var stale []bool
+	for range 5 {
+		trial, err := tb.before()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale = append(stale, trial)
+	}
+
+	tb.open(t)
+	openedAt := tb.openedAt
+
+	tb.now = tb.now.Add(20 * time.Second)
+	for _, trial := range stale {
+		tb.after(errUnavailable, trial)
+	}
+
+	if !tb.openedAt.Equal(openedAt) {
+		t.Fatalf("openedAt = %s, want the cool-down not to be extended from %s", tb.openedAt, openedAt)
+	}
+	tb.now = tb.now.Add(10 * time.Second)
+	tb.assertState(t, StateHalfOpen)
+}
+
+func TestBreakerIgnoresStaleCallsDuringTrial(t *testing.T) {
+	tb := newTestBreaker()
+
+	staleTrial, err := tb.before()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tb.open(t)
+	tb.now = tb.now.Add(30 * time.Second)
+
+	trial, err := tb.before()
+	if err != nil || !trial {
+		t.Fatalf("before = %v, %v, want a trial call", trial, err)
+	}
+
+	// a call from before the breaker opened succeeds during the trial
+	tb.after(nil, staleTrial)
+	tb.assertState(t, StateHalfOpen)
+
+	tb.after(errUnavailable, trial)
+	tb.assertState(t, StateOpen)
+}
+
+func TestBreakerInconclusiveCallsKeepTheFailureCount(t *testing.T) {
+	tb := newTestBreaker()
+
+	tb.call(t, errUnavailable)
+	tb.call(t, errUnavailable)
+	tb.call(t, context.Canceled)
+	tb.call(t, errUnavailable)
+	tb.assertState(t, StateOpen)
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Cache stores serialized values shared by the repositories.
+// Get reports a miss with ok false and a nil error.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Ping(ctx context.Context) error
+}
+
+// New creates the cache backend selected by cfg.Cache.Backend.
+func New(cfg *config.Configuration) (Cache, error) {
+	switch cfg.Cache.Backend {
+	case BackendMemory, "":
+		return NewMemoryCache(cfg.Cache.MaxEntries), nil
+	case BackendRedis:
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+
+		return NewRedisCache(client, cfg.Redis.KeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Cache.Backend)
+	}
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryCache is an in-process cache bounded to maxEntries.
+// The least recently used entry is evicted once maxEntries is reached.
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	now        func() time.Time
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewMemoryCache(maxEntries int) Cache {
+	return newMemoryCache(maxEntries, time.Now)
+}
+
+func newMemoryCache(maxEntries int, now func() time.Time) *memoryCache {
+	return &memoryCache{
+		maxEntries: maxEntries,
+		now:        now,
+		ll:         list.New(),
+		items:      map[string]*list.Element{},
+	}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(element)
+		return nil, false, nil
+	}
+
+	c.ll.MoveToFront(element)
+
+	return entry.value, true, nil
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(element)
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryEntry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+
+	return nil
+}
+
+func (c *memoryCache) Ping(_ context.Context) error {
+	return nil
+}
+
+func (c *memoryCache) removeElement(element *list.Element) {
+	c.ll.Remove(element)
+	delete(c.items, element.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func assertCached(t *testing.T, c Cache, key string, want string) {
+	t.Helper()
+
+	value, ok, err := c.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%s) error = %v", key, err)
+	}
+	if want == "" {
+		if ok {
+			t.Fatalf("Get(%s) = %q, want a miss", key, value)
+		}
+		return
+	}
+	if !ok || string(value) != want {
+		t.Fatalf("Get(%s) = %q, %v, want %q", key, value, ok, want)
+	}
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)}
+	c := newMemoryCache(10, clock.Now)
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "a", []byte("1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(59 * time.Second)
+	assertCached(t, c, "a", "1")
+
+	clock.Advance(time.Second)
+	assertCached(t, c, "a", "")
+	if c.ll.Len() != 0 {
+		t.Fatalf("entries = %d, want the expired entry to be removed", c.ll.Len())
+	}
+}
+
+func TestMemoryCacheSetRefreshesTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)}
+	c := newMemoryCache(10, clock.Now)
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "a", []byte("1"), time.Minute)
+	clock.Advance(50 * time.Second)
+	_ = c.Set(ctx, "a", []byte("2"), time.Minute)
+	clock.Advance(50 * time.Second)
+
+	assertCached(t, c, "a", "2")
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)}
+	c := newMemoryCache(2, clock.Now)
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "a", []byte("1"), time.Hour)
+	_ = c.Set(ctx, "b", []byte("2"), time.Hour)
+	// reading a makes b the least recently used entry
+	assertCached(t, c, "a", "1")
+	_ = c.Set(ctx, "c", []byte("3"), time.Hour)
+
+	assertCached(t, c, "b", "")
+	assertCached(t, c, "a", "1")
+	assertCached(t, c, "c", "3")
+	if c.ll.Len() != 2 {
+		t.Fatalf("entries = %d, want 2", c.ll.Len())
+	}
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisCache shares cached values between replicas through a Redis server.
+// Expiry and eviction are left to Redis.
+type redisCache struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+func NewRedisCache(client redis.UniversalClient, keyPrefix string) Cache {
+	return &redisCache{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.keyPrefix+key, value, ttl).Err()
+}
+
+func (c *redisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisCache(t *testing.T) (Cache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisCache(client, "test:"), server
+}
+
+func TestRedisCacheGetSet(t *testing.T) {
+	c, server := newTestRedisCache(t)
+	ctx := context.Background()
+
+	assertCached(t, c, "a", "")
+
+	if err := c.Set(ctx, "a", []byte("1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	assertCached(t, c, "a", "1")
+
+	if !server.Exists("test:a") {
+		t.Fatal("want the key to be stored with its prefix")
+	}
+	if ttl := server.TTL("test:a"); ttl != time.Minute {
+		t.Fatalf("ttl = %s, want 1m", ttl)
+	}
+}
+
+func TestRedisCacheTTL(t *testing.T) {
+	c, server := newTestRedisCache(t)
+
+	_ = c.Set(context.Background(), "a", []byte("1"), time.Minute)
+
+	server.FastForward(59 * time.Second)
+	assertCached(t, c, "a", "1")
+
+	server.FastForward(time.Second)
+	assertCached(t, c, "a", "")
+}
+
+func TestRedisCacheUnavailable(t *testing.T) {
+	c, server := newTestRedisCache(t)
+	ctx := context.Background()
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping error = %v, want nil", err)
+	}
+
+	server.Close()
+
+	if err := c.Ping(ctx); err == nil {
+		t.Fatal("Ping error = nil, want an error once Redis is down")
+	}
+	if _, _, err := c.Get(ctx, "a"); err == nil {
+		t.Fatal("Get error = nil, want an error once Redis is down")
+	}
+}
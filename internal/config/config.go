@@ -1,19 +1,33 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/olajoe/forecast_weather_api/pkg/logging"
 	"github.com/spf13/viper"
 )
 
 type Configuration struct {
-	Port     int
-	Cors     CorsConfig
-	LogLevel logging.Level `mapstructure:"log_level"`
+	Port      int
+	Cors      CorsConfig
+	LogLevel  logging.Level `mapstructure:"log_level"`
+	AccessLog logging.AccessLogOptions
 
-	Tmd TmdConfig
+	Tmd     TmdConfig
+	Cache   CacheConfig
+	Redis   RedisConfig
+	Timeout TimeoutConfig
+	Batch   BatchConfig
+
+	Auth            AuthConfig
+	ClientRateLimit ClientRateLimitConfig
+
+	Tracing   TracingConfig
+	Readiness ReadinessConfig
 }
 
 type CorsConfig struct {
@@ -23,6 +37,120 @@ type CorsConfig struct {
 type TmdConfig struct {
 	Url         string
 	AccessToken string
+	Timeout     time.Duration // per attempt
+	Retry       RetryConfig
+	Breaker     BreakerConfig
+	RateLimit   RateLimitConfig
+}
+
+type RetryConfig struct {
+	Count      int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	Budget     time.Duration // total time for all attempts, 0 means unlimited
+}
+
+type RateLimitConfig struct {
+	Rate    float64 // TMD requests per second, retries included, 0 disables the limiter
+	Burst   int
+	MaxWait time.Duration // how long a call may queue for a token, 0 fails fast
+}
+
+type BreakerConfig struct {
+	FailureThreshold int // consecutive failures before opening, 0 disables the breaker
+	CoolDown         time.Duration
+}
+
+// TimeoutConfig bounds how long each endpoint may take including every TMD call.
+// Endpoints without their own timeout use Default.
+type TimeoutConfig struct {
+	Default           time.Duration
+	DailyCoordinates  time.Duration
+	DailyPlace        time.Duration
+	DailyRegion       time.Duration
+	HourlyCoordinates time.Duration
+	HourlyPlace       time.Duration
+	DailyBatch        time.Duration
+}
+
+// defaultBatchMaxSize also applies when BATCH_MAX_SIZE is 0 or less.
+const defaultBatchMaxSize = 500
+
+type BatchConfig struct {
+	MaxSize     int // maximum number of queries per batch request
+	Concurrency int // maximum number of concurrent TMD calls per batch request
+}
+
+type CacheConfig struct {
+	Backend    string // memory or redis
+	TTL        time.Duration
+	StaleTTL   time.Duration // how long entries are kept to serve while TMD is down
+	MaxEntries int
+}
+
+// AuthConfig selects how /v1 callers authenticate, none, api_key or jwt.
+// API keys are read from a JSON file and from a name:hash:scopes list.
+type AuthConfig struct {
+	Mode        string
+	APIKeysFile string
+	APIKeys     string
+	JWT         JWTConfig
+}
+
+// JWTConfig verifies bearer tokens against a JWKS file, or a JWKS URL that is
+// fetched again every JWKSRefreshInterval.
+type JWTConfig struct {
+	JWKSFile            string
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+	Issuer              string
+	Audience            string
+	ScopeClaim          string // claim holding the scopes, e.g. scope or scp
+}
+
+// RedisConfig is shared by every backend that can keep its state in Redis.
+type RedisConfig struct {
+	Addr      string
+	Password  string
+	DB        int
+	KeyPrefix string
+}
+
+// ClientRateLimitConfig limits requests per API key, or per client IP for
+// requests without one.
+type ClientRateLimitConfig struct {
+	Enabled     bool
+	Backend     string // memory or redis
+	Tiers       map[string]RateLimitTier
+	DefaultTier string
+	ClientTiers map[string]string // client name, or API key without auth, to tier name; others use DefaultTier
+	TrustProxy  bool              // take the client IP from X-Forwarded-For
+}
+
+// RateLimitTier allows Limit requests per Window.
+type RateLimitTier struct {
+	Limit  int
+	Window time.Duration
+}
+
+// TracingConfig selects where spans are exported, none, stdout or otlp. The
+// OTLP exporter falls back to the OTEL_EXPORTER_OTLP_* variables when
+// OTLPEndpoint is empty.
+type TracingConfig struct {
+	Exporter     string
+	ServiceName  string
+	SampleRatio  float64 // share of new traces sampled, traces started upstream follow their caller
+	OTLPEndpoint string
+}
+
+// ReadinessConfig bounds each /readyz check by Timeout and reuses the report
+// for CacheTTL. TMDProbe is off by default: with it on every replica goes
+// unready at once while TMD is down, instead of serving stale cached forecasts
+// and failing fast through the circuit breaker.
+type ReadinessConfig struct {
+	Timeout  time.Duration
+	CacheTTL time.Duration
+	TMDProbe bool
 }
 
 func New() *Configuration {
@@ -36,6 +164,45 @@ func New() *Configuration {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("timeout_default", "15s")
+	viper.SetDefault("timeout_daily_batch", "60s")
+	viper.SetDefault("tmd_timeout", "5s")
+	viper.SetDefault("tmd_retry_count", 2)
+	viper.SetDefault("tmd_retry_min_backoff", "100ms")
+	viper.SetDefault("tmd_retry_max_backoff", "2s")
+	viper.SetDefault("tmd_retry_budget", "10s")
+	viper.SetDefault("tmd_breaker_failure_threshold", 5)
+	viper.SetDefault("tmd_breaker_cool_down", "30s")
+	viper.SetDefault("tmd_rate_limit_rate", 10)
+	viper.SetDefault("tmd_rate_limit_burst", 20)
+	viper.SetDefault("tmd_rate_limit_max_wait", "2s")
+	viper.SetDefault("cache_backend", "memory")
+	viper.SetDefault("cache_ttl", "5m")
+	viper.SetDefault("cache_stale_ttl", "1h")
+	viper.SetDefault("cache_max_entries", 1000)
+	viper.SetDefault("redis_key_prefix", "forecast_weather_api:")
+	viper.SetDefault("auth_mode", "none")
+	viper.SetDefault("auth_jwt_jwks_refresh_interval", "1h")
+	viper.SetDefault("auth_jwt_scope_claim", "scope")
+	viper.SetDefault("client_rate_limit_enabled", false)
+	viper.SetDefault("client_rate_limit_trust_proxy", false)
+	viper.SetDefault("client_rate_limit_backend", "memory")
+	viper.SetDefault("client_rate_limit_tiers", "default:120/1m")
+	viper.SetDefault("client_rate_limit_default_tier", "default")
+	viper.SetDefault("batch_max_size", defaultBatchMaxSize)
+	viper.SetDefault("batch_concurrency", 8)
+	viper.SetDefault("access_log_fields", "bytes,user_agent,client_ip,route")
+	viper.SetDefault("access_log_success_sample_rate", 1.0)
+	viper.SetDefault("access_log_redact_headers", "Authorization,X-Api-Key,X-Secret-Key,Cookie")
+	viper.SetDefault("readiness_timeout", "2s")
+	viper.SetDefault("readiness_cache_ttl", "5s")
+	viper.SetDefault("readiness_tmd_probe", false)
+	viper.SetDefault("tracing_exporter", "none")
+	viper.SetDefault("tracing_service_name", "forecast-weather-api")
+	viper.SetDefault("tracing_sample_ratio", 1.0)
+
+	defaultTimeout := viper.GetDuration("timeout_default")
+
 	cfg := Configuration{
 		Port: viper.GetInt("port"),
 		Cors: CorsConfig{
@@ -43,12 +210,167 @@ func New() *Configuration {
 			Origins: "*",
 		},
 		LogLevel: logging.Level(viper.GetInt("log_level")),
+		AccessLog: logging.AccessLogOptions{
+			Fields:            splitList(viper.GetString("access_log_fields")),
+			SuccessSampleRate: viper.GetFloat64("access_log_success_sample_rate"),
+			RedactHeaders:     splitList(viper.GetString("access_log_redact_headers")),
+		},
 
 		Tmd: TmdConfig{
 			Url:         viper.GetString("tmd_url"),
 			AccessToken: viper.GetString("tmd_access_token"),
+			Timeout:     viper.GetDuration("tmd_timeout"),
+			Retry: RetryConfig{
+				Count:      viper.GetInt("tmd_retry_count"),
+				MinBackoff: viper.GetDuration("tmd_retry_min_backoff"),
+				MaxBackoff: viper.GetDuration("tmd_retry_max_backoff"),
+				Budget:     viper.GetDuration("tmd_retry_budget"),
+			},
+			Breaker: BreakerConfig{
+				FailureThreshold: viper.GetInt("tmd_breaker_failure_threshold"),
+				CoolDown:         viper.GetDuration("tmd_breaker_cool_down"),
+			},
+			RateLimit: RateLimitConfig{
+				Rate:    viper.GetFloat64("tmd_rate_limit_rate"),
+				Burst:   viper.GetInt("tmd_rate_limit_burst"),
+				MaxWait: viper.GetDuration("tmd_rate_limit_max_wait"),
+			},
+		},
+		Cache: CacheConfig{
+			Backend:    viper.GetString("cache_backend"),
+			TTL:        viper.GetDuration("cache_ttl"),
+			StaleTTL:   viper.GetDuration("cache_stale_ttl"),
+			MaxEntries: viper.GetInt("cache_max_entries"),
+		},
+		Redis: RedisConfig{
+			Addr:      viper.GetString("redis_addr"),
+			Password:  viper.GetString("redis_password"),
+			DB:        viper.GetInt("redis_db"),
+			KeyPrefix: viper.GetString("redis_key_prefix"),
+		},
+		Timeout: TimeoutConfig{
+			Default:           defaultTimeout,
+			DailyCoordinates:  getDurationOr("timeout_daily_coordinates", defaultTimeout),
+			DailyPlace:        getDurationOr("timeout_daily_place", defaultTimeout),
+			DailyRegion:       getDurationOr("timeout_daily_region", defaultTimeout),
+			HourlyCoordinates: getDurationOr("timeout_hourly_coordinates", defaultTimeout),
+			HourlyPlace:       getDurationOr("timeout_hourly_place", defaultTimeout),
+			DailyBatch:        getDurationOr("timeout_daily_batch", defaultTimeout),
+		},
+		Batch: BatchConfig{
+			MaxSize:     getIntOr("batch_max_size", defaultBatchMaxSize),
+			Concurrency: viper.GetInt("batch_concurrency"),
+		},
+		Auth: AuthConfig{
+			Mode:        viper.GetString("auth_mode"),
+			APIKeysFile: viper.GetString("auth_api_keys_file"),
+			APIKeys:     viper.GetString("auth_api_keys"),
+			JWT: JWTConfig{
+				JWKSFile:            viper.GetString("auth_jwt_jwks_file"),
+				JWKSURL:             viper.GetString("auth_jwt_jwks_url"),
+				JWKSRefreshInterval: viper.GetDuration("auth_jwt_jwks_refresh_interval"),
+				Issuer:              viper.GetString("auth_jwt_issuer"),
+				Audience:            viper.GetString("auth_jwt_audience"),
+				ScopeClaim:          viper.GetString("auth_jwt_scope_claim"),
+			},
+		},
+		ClientRateLimit: ClientRateLimitConfig{
+			Enabled:     viper.GetBool("client_rate_limit_enabled"),
+			Backend:     viper.GetString("client_rate_limit_backend"),
+			Tiers:       map[string]RateLimitTier{},
+			DefaultTier: viper.GetString("client_rate_limit_default_tier"),
+			ClientTiers: parsePairs(viper.GetString("client_rate_limit_clients")),
+			TrustProxy:  viper.GetBool("client_rate_limit_trust_proxy"),
+		},
+		Tracing: TracingConfig{
+			Exporter:     viper.GetString("tracing_exporter"),
+			ServiceName:  viper.GetString("tracing_service_name"),
+			SampleRatio:  viper.GetFloat64("tracing_sample_ratio"),
+			OTLPEndpoint: viper.GetString("tracing_otlp_endpoint"),
+		},
+		Readiness: ReadinessConfig{
+			Timeout:  viper.GetDuration("readiness_timeout"),
+			CacheTTL: viper.GetDuration("readiness_cache_ttl"),
+			TMDProbe: viper.GetBool("readiness_tmd_probe"),
 		},
 	}
 
+	for name, value := range parsePairs(viper.GetString("client_rate_limit_tiers")) {
+		tier, err := parseRateLimitTier(value)
+		if err != nil {
+			log.Fatalf("Invalid rate limit tier %s, %s \n", name, err)
+		}
+		cfg.ClientRateLimit.Tiers[name] = tier
+	}
+	if _, ok := cfg.ClientRateLimit.Tiers[cfg.ClientRateLimit.DefaultTier]; cfg.ClientRateLimit.Enabled && !ok {
+		log.Fatalf("Unknown default rate limit tier %s \n", cfg.ClientRateLimit.DefaultTier)
+	}
+	for _, tier := range cfg.ClientRateLimit.ClientTiers {
+		if _, ok := cfg.ClientRateLimit.Tiers[tier]; cfg.ClientRateLimit.Enabled && !ok {
+			log.Fatalf("Unknown client rate limit tier %s \n", tier)
+		}
+	}
+
 	return &cfg
 }
+
+func getDurationOr(key string, fallback time.Duration) time.Duration {
+	if duration := viper.GetDuration(key); duration > 0 {
+		return duration
+	}
+
+	return fallback
+}
+
+func getIntOr(key string, fallback int) int {
+	if value := viper.GetInt(key); value > 0 {
+		return value
+	}
+
+	return fallback
+}
+
+// splitList reads a comma separated list and drops empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// parsePairs reads comma separated name:value pairs, e.g. "a:1,b:2".
+func parsePairs(value string) map[string]string {
+	pairs := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		pairs[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	return pairs
+}
+
+// parseRateLimitTier reads a tier written as limit/window, e.g. "120/1m".
+func parseRateLimitTier(value string) (RateLimitTier, error) {
+	limit, window, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimitTier{}, fmt.Errorf("%q is not limit/window", value)
+	}
+
+	tier := RateLimitTier{}
+	var err error
+	if tier.Limit, err = strconv.Atoi(limit); err != nil || tier.Limit <= 0 {
+		return RateLimitTier{}, fmt.Errorf("%q is not a positive limit", limit)
+	}
+	if tier.Window, err = time.ParseDuration(window); err != nil || tier.Window <= 0 {
+		return RateLimitTier{}, fmt.Errorf("%q is not a positive window", window)
+	}
+
+	return tier, nil
+}
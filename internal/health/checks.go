@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/olajoe/forecast_weather_api/internal/config"
+)
+
+// NewConfigCheck fails when the settings needed to call TMD are missing.
+func NewConfigCheck(cfg *config.Configuration) CheckFunc {
+	return func(_ context.Context) error {
+		var errs []error
+
+		if cfg.Tmd.Url == "" {
+			errs = append(errs, errors.New("TMD_URL is empty"))
+		} else if u, err := url.Parse(cfg.Tmd.Url); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("TMD_URL %q is not an absolute URL", cfg.Tmd.Url))
+		}
+		if cfg.Tmd.AccessToken == "" {
+			errs = append(errs, errors.New("TMD_ACCESS_TOKEN is empty"))
+		}
+
+		return errors.Join(errs...)
+	}
+}
+
+// NewTMDCheck requests the TMD base URL with the access token. Any response
+// other than a server error or a rejected token means TMD is reachable, the
+// probe does not call a forecast endpoint so it does not use up the TMD quota.
+func NewTMDCheck(cfg *config.Configuration) CheckFunc {
+	client := &http.Client{}
+
+	return func(ctx context.Context) error {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.Tmd.Url, nil)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", cfg.Tmd.AccessToken))
+
+		response, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+
+		switch {
+		case response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden:
+			return fmt.Errorf("TMD rejected the access token with status %d", response.StatusCode)
+		case response.StatusCode >= http.StatusInternalServerError:
+			return fmt.Errorf("TMD responded with status %d", response.StatusCode)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc reports whether a dependency is usable, it must return once ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+// DetailFunc reports the current state of a component, e.g. the TMD circuit
+// breaker, without affecting the status of the report.
+type DetailFunc func() any
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is ok only when every check is ok.
+type Report struct {
+	Status    string                 `json:"status"`
+	Checks    map[string]CheckResult `json:"checks"`
+	Details   map[string]any         `json:"details,omitempty"`
+	CheckedAt time.Time              `json:"checkedAt"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Readiness runs the registered checks concurrently, each bounded by timeout,
+// and keeps the report for cacheTTL so that frequent probes do not hit the
+// dependencies on every call.
+type Readiness struct {
+	mu       sync.Mutex
+	checks   []check
+	details  map[string]DetailFunc
+	timeout  time.Duration
+	cacheTTL time.Duration
+	report   *Report
+}
+
+func NewReadiness(timeout time.Duration, cacheTTL time.Duration) *Readiness {
+	return &Readiness{timeout: timeout, cacheTTL: cacheTTL, details: map[string]DetailFunc{}}
+}
+
+func (r *Readiness) Register(name string, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, check{name: name, fn: fn})
+	r.report = nil
+}
+
+// RegisterDetail adds the current value of fn to every report.
+func (r *Readiness) RegisterDetail(name string, fn DetailFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.details[name] = fn
+}
+
+// Report returns the cached report or runs the checks again once it expired.
+// Details are read on every call.
+// Concurrent callers wait for the same run, which is not cancelled with the
+// ctx of the caller that started it since its report is shared.
+func (r *Readiness) Report(ctx context.Context) Report {
+	ctx = context.WithoutCancel(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.report != nil && time.Since(r.report.CheckedAt) < r.cacheTTL {
+		return r.withDetails(*r.report)
+	}
+
+	report := Report{
+		Status:    StatusOK,
+		Checks:    make(map[string]CheckResult, len(r.checks)),
+		CheckedAt: time.Now(),
+	}
+
+	results := make([]CheckResult, len(r.checks))
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c.fn)
+		}()
+	}
+	wg.Wait()
+
+	for i, c := range r.checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	r.report = &report
+
+	return r.withDetails(report)
+}
+
+// Details returns the current value of every registered detail without
+// running the checks, nil when none is registered.
+func (r *Readiness) Details() map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.readDetails()
+}
+
+func (r *Readiness) withDetails(report Report) Report {
+	report.Details = r.readDetails()
+
+	return report
+}
+
+func (r *Readiness) readDetails() map[string]any {
+	if len(r.details) == 0 {
+		return nil
+	}
+
+	details := make(map[string]any, len(r.details))
+	for name, fn := range r.details {
+		details[name] = fn()
+	}
+
+	return details
+}
+
+func (r *Readiness) run(ctx context.Context, fn CheckFunc) CheckResult {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	err := fn(ctx)
+	result := CheckResult{Status: StatusOK, Duration: time.Since(startTime).String()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReadinessReport(t *testing.T) {
+	readiness := NewReadiness(time.Second, time.Minute)
+
+	checks := 0
+	readiness.Register("ok", func(context.Context) error {
+		checks++
+		return nil
+	})
+	readiness.Register("down", func(context.Context) error { return errors.New("down") })
+
+	state := "closed"
+	readiness.RegisterDetail("breaker", func() any { return state })
+
+	report := readiness.Report(context.Background())
+	if report.Status != StatusFail || report.Checks["ok"].Status != StatusOK || report.Checks["down"].Error != "down" {
+		t.Fatalf("report = %+v, want the down check to fail the report", report)
+	}
+	if report.Details["breaker"] != "closed" {
+		t.Fatalf("details = %v, want breaker closed", report.Details)
+	}
+
+	// details are current even when the checks come from the cached report
+	state = "open"
+	report = readiness.Report(context.Background())
+	if checks != 1 {
+		t.Fatalf("checks ran %d times, want the cached report to be reused", checks)
+	}
+	if report.Details["breaker"] != "open" {
+		t.Fatalf("details = %v, want breaker open", report.Details)
+	}
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/olajoe/forecast_weather_api/internal/breaker"
+	"github.com/olajoe/forecast_weather_api/internal/ratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// registry holds every metric served on /metrics.
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_server_request_duration_seconds",
+		Help:    "Duration of HTTP requests by mux route template, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	tmdRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tmd_request_duration_seconds",
+		Help:    "Duration of TMD calls including retries by endpoint and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint", "outcome"})
+
+	tmdRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tmd_retries_total",
+		Help: "Retried TMD requests by endpoint.",
+	}, []string{"endpoint"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		tmdRequestDuration,
+		tmdRetries,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records a served request. route must be a template such
+// as /v1/weathers/daily/place rather than the raw path to bound cardinality.
+func ObserveHTTPRequest(route string, method string, status int, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+func ObserveTMDRequest(endpoint string, outcome string, duration time.Duration) {
+	tmdRequestDuration.WithLabelValues(endpoint, outcome).Observe(duration.Seconds())
+}
+
+func AddTMDRetries(endpoint string, retries int) {
+	if retries > 0 {
+		tmdRetries.WithLabelValues(endpoint).Add(float64(retries))
+	}
+}
+
+// RegisterCircuitBreaker exports the state of the TMD breaker,
+// 0 closed, 1 open and 2 half-open.
+func RegisterCircuitBreaker(b *breaker.Breaker) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "tmd_circuit_breaker_state",
+		Help: "State of the TMD circuit breaker, 0 closed, 1 open and 2 half-open.",
+	}, func() float64 {
+		return float64(b.State())
+	}))
+}
+
+// RegisterRateLimiter exports how many TMD calls got a token and how long they
+// waited for it, per call in tmd_rate_limit_wait_seconds for percentiles.
+func RegisterRateLimiter(l *ratelimit.Limiter) {
+	waitDuration := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tmd_rate_limit_wait_seconds",
+		Help:    "Time each TMD call that got a token waited for it.",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+	})
+	l.ObserveWaits(func(wait time.Duration) {
+		waitDuration.Observe(wait.Seconds())
+	})
+
+	registry.MustRegister(
+		waitDuration,
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "tmd_rate_limit_allowed_total",
+			Help: "TMD calls that got a token.",
+		}, func() float64 {
+			return float64(l.Stats().Allowed)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "tmd_rate_limit_rejected_total",
+			Help: "TMD calls rejected for lack of a token.",
+		}, func() float64 {
+			return float64(l.Stats().Rejected)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "tmd_rate_limit_waited_total",
+			Help: "TMD calls that had to wait for a token.",
+		}, func() float64 {
+			return float64(l.Stats().Waited)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "tmd_rate_limit_wait_seconds_total",
+			Help: "Time TMD calls spent waiting for a token.",
+		}, func() float64 {
+			return l.Stats().WaitSecondsTotal
+		}),
+	)
+}
+
+// RegisterCache exports the hit, miss and stale hit counts of the forecast cache.
+func RegisterCache(stats func() (hits int64, misses int64, staleHits int64)) {
+	registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "weather_cache_hits_total",
+			Help: "Forecasts served from the cache.",
+		}, func() float64 {
+			hits, _, _ := stats()
+			return float64(hits)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "weather_cache_misses_total",
+			Help: "Forecasts not found fresh in the cache.",
+		}, func() float64 {
+			_, misses, _ := stats()
+			return float64(misses)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "weather_cache_stale_hits_total",
+			Help: "Expired forecasts served while TMD was unavailable or rate limited.",
+		}, func() float64 {
+			_, _, staleHits := stats()
+			return float64(staleHits)
+		}),
+	)
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
+)
+
+// NewMiddleware observes the duration of every request by route template.
+func NewMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			startTime := time.Now()
+			sw := logging.NewResponseWriter(w)
+
+			next.ServeHTTP(sw, r)
+
+			ObserveHTTPRequest(logging.RouteTemplate(r), r.Method, sw.Status(), time.Since(startTime))
+		})
+	}
+}
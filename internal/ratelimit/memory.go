@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often ended windows are dropped from memoryStore.
+const sweepInterval = time.Minute
+
+// memoryStore keeps the windows of a single process.
+type memoryStore struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	count int
+	end   time.Time
+}
+
+func NewMemoryStore() Store {
+	return newMemoryStore(time.Now)
+}
+
+func newMemoryStore(now func() time.Time) *memoryStore {
+	return &memoryStore{
+		now:       now,
+		windows:   map[string]*memoryWindow{},
+		lastSweep: now(),
+	}
+}
+
+func (s *memoryStore) Take(_ context.Context, key string, limit int, window time.Duration) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.end) {
+		w = &memoryWindow{end: now.Truncate(window).Add(window)}
+		s.windows[key] = w
+	}
+	w.count++
+
+	return newResult(w.count, limit, w.end, now), nil
+}
+
+func (s *memoryStore) sweep(now time.Time) {
+	for key, w := range s.windows {
+		if !now.Before(w.end) {
+			delete(s.windows, key)
+		}
+	}
+	s.lastSweep = now
+}
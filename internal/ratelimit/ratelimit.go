@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var ErrLimited = errors.New("rate limit exceeded")
+
+// Stats reports how many calls got a token and how long they waited for it.
+type Stats struct {
+	Allowed          int64   `json:"allowed"`
+	Rejected         int64   `json:"rejected"`
+	Waited           int64   `json:"waited"` // allowed calls that had to wait for a token
+	WaitSecondsTotal float64 `json:"waitSecondsTotal"`
+	MaxWaitSeconds   float64 `json:"maxWaitSeconds"`
+}
+
+// Limiter is a token bucket refilled at ratePerSecond that holds up to burst
+// tokens. Calls queue for a token up to maxWait but never past the deadline of
+// their context, a maxWait of 0 fails fast with ErrLimited.
+type Limiter struct {
+	limiter *rate.Limiter
+	maxWait time.Duration
+
+	allowed   atomic.Int64
+	rejected  atomic.Int64
+	waited    atomic.Int64
+	waitTotal atomic.Int64
+	waitMax   atomic.Int64
+
+	observeWait func(time.Duration)
+}
+
+func New(ratePerSecond float64, burst int, maxWait time.Duration) *Limiter {
+	return &Limiter{
+		limiter: rate.NewLimiter(rate.Limit(ratePerSecond), max(burst, 1)),
+		maxWait: maxWait,
+	}
+}
+
+// ObserveWaits calls observe with the time every allowed call waited for its
+// token, 0 when one was available. It must be set before the limiter is used.
+func (l *Limiter) ObserveWaits(observe func(time.Duration)) {
+	l.observeWait = observe
+}
+
+// Wait takes a token, blocking until one is available. It returns ErrLimited
+// without waiting when the token would come later than maxWait or the context
+// deadline, and the context error when ctx is done while waiting.
+func (l *Limiter) Wait(ctx context.Context) error {
+	reservation := l.limiter.Reserve()
+
+	delay := reservation.Delay()
+	if delay == 0 {
+		l.allowed.Add(1)
+		l.observe(0)
+		return nil
+	}
+
+	if delay > l.maxWait || exceedsDeadline(ctx, delay) {
+		reservation.Cancel()
+		l.rejected.Add(1)
+		return fmt.Errorf("%w: next token in %s", ErrLimited, delay)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	l.allowed.Add(1)
+	l.waited.Add(1)
+	l.waitTotal.Add(int64(delay))
+	for {
+		waitMax := l.waitMax.Load()
+		if int64(delay) <= waitMax || l.waitMax.CompareAndSwap(waitMax, int64(delay)) {
+			break
+		}
+	}
+	l.observe(delay)
+
+	return nil
+}
+
+func (l *Limiter) observe(wait time.Duration) {
+	if l.observeWait != nil {
+		l.observeWait(wait)
+	}
+}
+
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		Allowed:          l.allowed.Load(),
+		Rejected:         l.rejected.Load(),
+		Waited:           l.waited.Load(),
+		WaitSecondsTotal: time.Duration(l.waitTotal.Load()).Seconds(),
+		MaxWaitSeconds:   time.Duration(l.waitMax.Load()).Seconds(),
+	}
+}
+
+func exceedsDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < delay
+}
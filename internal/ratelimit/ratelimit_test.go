@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterObservesWaits(t *testing.T) {
+	limiter := New(100, 1, 50*time.Millisecond)
+
+	var waits []time.Duration
+	limiter.ObserveWaits(func(wait time.Duration) {
+		waits = append(waits, wait)
+	})
+
+	for range 2 {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the burst is spent and the next token comes after maxWait
+	for range 10 {
+		_ = limiter.limiter.Reserve()
+	}
+	if err := limiter.Wait(context.Background()); !errors.Is(err, ErrLimited) {
+		t.Fatalf("Wait error = %v, want ErrLimited", err)
+	}
+
+	if len(waits) != 2 || waits[0] != 0 || waits[1] <= 0 || waits[1] > 10*time.Millisecond {
+		t.Fatalf("waits = %v, want no wait then one of up to 10ms and none for the rejected call", waits)
+	}
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStore shares windows between replicas through a Redis server.
+// Each window is a counter that expires when the window ends.
+type redisStore struct {
+	client    redis.UniversalClient
+	keyPrefix string
+	now       func() time.Time
+}
+
+func NewRedisStore(client redis.UniversalClient, keyPrefix string) Store {
+	return newRedisStore(client, keyPrefix, time.Now)
+}
+
+func newRedisStore(client redis.UniversalClient, keyPrefix string, now func() time.Time) *redisStore {
+	return &redisStore{
+		client:    client,
+		keyPrefix: keyPrefix,
+		now:       now,
+	}
+}
+
+func (s *redisStore) Take(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	now := s.now()
+	windowEnd := now.Truncate(window).Add(window)
+	redisKey := s.keyPrefix + "ratelimit:" + key + ":" + strconv.FormatInt(windowEnd.Unix(), 10)
+
+	pipe := s.client.TxPipeline()
+	count := pipe.Incr(ctx, redisKey)
+	pipe.ExpireAt(ctx, redisKey, windowEnd)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Result{}, err
+	}
+
+	return newResult(int(count.Val()), limit, windowEnd, now), nil
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStoreTake(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	now := time.Date(2026, 10, 18, 10, 0, 15, 0, time.UTC)
+	store := newRedisStore(client, "test:", func() time.Time { return now })
+	server.SetTime(now)
+	ctx := context.Background()
+
+	take := func(key string) Result {
+		t.Helper()
+
+		result, err := store.Take(ctx, key, 2, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return result
+	}
+
+	if result := take("a"); !result.Allowed || result.Remaining != 1 || result.Reset != 45*time.Second {
+		t.Fatalf("first take = %+v", result)
+	}
+	if result := take("a"); !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("second take = %+v", result)
+	}
+	if result := take("a"); result.Allowed || result.Remaining != 0 {
+		t.Fatalf("third take = %+v, want it rejected", result)
+	}
+	if result := take("b"); !result.Allowed {
+		t.Fatalf("other key = %+v, want its own window", result)
+	}
+
+	// windows are keyed by their end, 2026-10-18T10:01:00Z
+	windowKey := "test:ratelimit:a:1792317660"
+	if ttl := server.TTL(windowKey); ttl != 45*time.Second {
+		t.Fatalf("ttl of %s = %s, want it to expire with the window", windowKey, ttl)
+	}
+
+	now = now.Add(45 * time.Second)
+	server.FastForward(45 * time.Second)
+	if server.Exists(windowKey) {
+		t.Fatalf("%s still exists after its window ended", windowKey)
+	}
+	if result := take("a"); !result.Allowed || result.Remaining != 1 {
+		t.Fatalf("take in next window = %+v", result)
+	}
+}
+
+func TestRedisStoreUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	store := NewRedisStore(client, "test:")
+
+	server.Close()
+
+	if _, err := store.Take(context.Background(), "a", 1, time.Minute); err == nil {
+		t.Fatal("error = nil, want an error once Redis is down")
+	}
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Result is the state of a key's window after counting one request.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration // until the window ends
+}
+
+// Store counts requests per key in fixed windows of the given length.
+type Store interface {
+	Take(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+}
+
+// NewStore creates the store backend selected by cfg.ClientRateLimit.Backend.
+func NewStore(cfg *config.Configuration) (Store, error) {
+	switch cfg.ClientRateLimit.Backend {
+	case BackendMemory, "":
+		return NewMemoryStore(), nil
+	case BackendRedis:
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+
+		return NewRedisStore(client, cfg.Redis.KeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.ClientRateLimit.Backend)
+	}
+}
+
+func newResult(count int, limit int, windowEnd time.Time, now time.Time) Result {
+	return Result{
+		Allowed:   count <= limit,
+		Limit:     limit,
+		Remaining: max(limit-count, 0),
+		Reset:     windowEnd.Sub(now),
+	}
+}
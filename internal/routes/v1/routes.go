@@ -2,13 +2,39 @@ package v1
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/olajoe/forecast_weather_api/internal/auth"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/olajoe/forecast_weather_api/internal/utils/https"
 	"github.com/olajoe/forecast_weather_api/internal/weather"
 )
 
-func RegisterRoutes(r *mux.Router, weatherHandler *weather.WeatherHandler) {
+func RegisterRoutes(r *mux.Router, cfg *config.Configuration, weatherHandler *weather.WeatherHandler) {
+	timeouts := cfg.Timeout
+	read := requireScope(cfg, auth.ScopeForecastRead)
+	batch := requireScope(cfg, auth.ScopeForecastBatch)
+
 	corporateApi := r.PathPrefix("/weathers").Subrouter()
-	corporateApi.HandleFunc("/daily/coordinates", weatherHandler.GetWeatherForecastDailyByCoordinates).Methods(http.MethodGet)
-	corporateApi.HandleFunc("/daily/place", weatherHandler.GetWeatherForecastDailyByPlace).Methods(http.MethodGet)
+	corporateApi.Handle("/daily/coordinates", read(withTimeout(timeouts.DailyCoordinates, weatherHandler.GetWeatherForecastDailyByCoordinates))).Methods(http.MethodGet)
+	corporateApi.Handle("/daily/place", read(withTimeout(timeouts.DailyPlace, weatherHandler.GetWeatherForecastDailyByPlace))).Methods(http.MethodGet)
+	corporateApi.Handle("/daily/region", read(withTimeout(timeouts.DailyRegion, weatherHandler.GetWeatherForecastDailyByRegion))).Methods(http.MethodGet)
+	corporateApi.Handle("/hourly/coordinates", read(withTimeout(timeouts.HourlyCoordinates, weatherHandler.GetWeatherForecastHourlyByCoordinates))).Methods(http.MethodGet)
+	corporateApi.Handle("/hourly/place", read(withTimeout(timeouts.HourlyPlace, weatherHandler.GetWeatherForecastHourlyByPlace))).Methods(http.MethodGet)
+	corporateApi.Handle("/daily/batch", batch(withTimeout(timeouts.DailyBatch, weatherHandler.GetWeatherForecastDailyBatch))).Methods(http.MethodPost)
+}
+
+func withTimeout(timeout time.Duration, handler http.HandlerFunc) http.Handler {
+	return https.NewMiddlewareTimeout(timeout)(handler)
+}
+
+// requireScope checks the scope of the authenticated caller, there is nothing
+// to check when auth is off.
+func requireScope(cfg *config.Configuration, scope string) func(http.Handler) http.Handler {
+	if cfg.Auth.Mode == auth.ModeNone || cfg.Auth.Mode == "" {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return https.NewMiddlewareRequireScope(scope)
 }
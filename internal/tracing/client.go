@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"github.com/imroc/req/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentClient starts a client span for every attempt made by client,
+// retries included, and injects its traceparent into the request headers.
+func InstrumentClient(client *req.Client) *req.Client {
+	tracer := otel.Tracer(instrumentationName)
+
+	return client.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+		return func(r *req.Request) (*req.Response, error) {
+			ctx, span := tracer.Start(r.Context(), r.Method,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLFull(r.RawURL),
+					semconv.HTTPRequestResendCount(r.RetryAttempt),
+				),
+			)
+			defer span.End()
+
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Headers))
+
+			resp, err := rt.RoundTrip(r)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return resp, err
+			}
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+
+			return resp, nil
+		}
+	})
+}
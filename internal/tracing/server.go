@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/olajoe/forecast_weather_api/internal/tracing"
+
+// NewMiddleware starts a server span per request, continuing the trace of an
+// incoming traceparent header. Spans are named by mux route template.
+func NewMiddleware() func(http.Handler) http.Handler {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			route := logging.RouteTemplate(r)
+			ctx, span := tracer.Start(ctx, fmt.Sprintf("%s %s", r.Method, route),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.HTTPRoute(route),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			sw := logging.NewResponseWriter(w)
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			statusCode := sw.Status()
+			span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+			if statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(statusCode))
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package https
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/olajoe/forecast_weather_api/internal/auth"
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
+	"github.com/rs/zerolog"
+)
+
+// authFailure is why a request could not be authenticated and the
+// WWW-Authenticate challenge to answer it with, if any.
+type authFailure struct {
+	err       error
+	challenge string
+}
+
+type authFailureContextKey struct{}
+
+// NewMiddlewareAPIKeyAuth puts the identity of a known x-api-key into the
+// request context and its logger. Other requests go on anonymous so that the
+// rate limiter counts failed attempts, NewMiddlewareRequireAuth rejects them.
+func NewMiddlewareAPIKeyAuth(store *auth.APIKeyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := store.Authenticate(r.Header.Get(HeaderAPIKey))
+			if err != nil {
+				next.ServeHTTP(w, r.WithContext(withAuthFailure(r, authFailure{err: err})))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withIdentity(r, identity)))
+		})
+	}
+}
+
+// NewMiddlewareJWTAuth puts the identity of a valid Authorization: Bearer
+// token into the request context and its logger. Other requests go on
+// anonymous, NewMiddlewareRequireAuth rejects them.
+func NewMiddlewareJWTAuth(verifier *auth.JWTVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, _ := strings.CutPrefix(r.Header.Get(HeaderAuthorizationKey), "Bearer ")
+
+			identity, err := verifier.Authenticate(r.Context(), strings.TrimSpace(token))
+			if err != nil {
+				failure := authFailure{err: err, challenge: `Bearer error="invalid_token"`}
+				if errors.Is(err, auth.ErrMissingCredentials) {
+					failure.challenge = "Bearer"
+				}
+
+				next.ServeHTTP(w, r.WithContext(withAuthFailure(r, failure)))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withIdentity(r, identity)))
+		})
+	}
+}
+
+// NewMiddlewareRequireAuth rejects requests that one of the auth middlewares
+// could not authenticate.
+func NewMiddlewareRequireAuth() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := auth.FromContext(r.Context()); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			failure, ok := r.Context().Value(authFailureContextKey{}).(authFailure)
+			if !ok {
+				failure = authFailure{err: auth.ErrMissingCredentials}
+			}
+			if failure.challenge != "" {
+				w.Header().Set("WWW-Authenticate", failure.challenge)
+			}
+
+			WriteError(w, r, NewErrorResponseUnauthorized(failure.err))
+		})
+	}
+}
+
+// NewMiddlewareRequireScope rejects callers without scope. It expects one of
+// the auth middlewares to run first.
+func NewMiddlewareRequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := auth.FromContext(r.Context())
+			if !ok {
+				WriteError(w, r, NewErrorResponseUnauthorized(auth.ErrMissingCredentials))
+				return
+			}
+
+			if !identity.HasScope(scope) {
+				WriteError(w, r, NewErrorResponseForbidden(fmt.Errorf("missing scope %s", scope)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func withAuthFailure(r *http.Request, failure authFailure) context.Context {
+	return context.WithValue(r.Context(), authFailureContextKey{}, failure)
+}
+
+func withIdentity(r *http.Request, identity auth.Identity) context.Context {
+	logger := logging.Ctx(r.Context())
+	logger.UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Str("client", identity.Name)
+	})
+
+	return auth.NewContext(r.Context(), identity)
+}
@@ -0,0 +1,41 @@
+package https
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olajoe/forecast_weather_api/internal/auth"
+)
+
+func TestMiddlewareRequireScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		wantStatus int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"missing scope", &auth.Identity{Name: "client-1", Scopes: []string{auth.ScopeForecastRead}}, http.StatusForbidden},
+		{"has scope", &auth.Identity{Name: "client-1", Scopes: []string{auth.ScopeForecastRead, auth.ScopeForecastBatch}}, http.StatusOK},
+	}
+
+	handler := NewMiddlewareRequireScope(auth.ScopeForecastBatch)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/weathers/daily/batch", nil)
+			if tt.identity != nil {
+				r = r.WithContext(auth.NewContext(r.Context(), *tt.identity))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
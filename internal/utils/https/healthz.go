@@ -3,18 +3,50 @@ package https
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/olajoe/forecast_weather_api/internal/health"
 )
 
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+// NewLivenessHandler answers as long as the process serves HTTP, it does not
+// check any dependency so that a TMD outage does not restart the pod.
+func NewLivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": health.StatusOK})
+	}
+}
+
+// NewHealthCheckHandler always answers 200 like NewLivenessHandler, since
+// existing deployments probe /healthz for liveness, and reports the details of
+// readiness, e.g. the TMD circuit breaker state, without running its checks.
+func NewHealthCheckHandler(readiness *health.Readiness) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
 
-	response := map[string]string{
-		"status":  "success",
-		"message": "Service is running",
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status":  health.StatusOK,
+			"details": readiness.Details(),
+		})
 	}
+}
+
+// NewReadinessHandler responds with the report of every check, 200 when all of
+// them pass and 503 otherwise so that the pod stops receiving traffic.
+func NewReadinessHandler(readiness *health.Readiness) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := readiness.Report(r.Context())
+
+		status := http.StatusOK
+		if report.Status != health.StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(report)
 	}
 }
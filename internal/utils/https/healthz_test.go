@@ -0,0 +1,39 @@
+package https
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/olajoe/forecast_weather_api/internal/health"
+)
+
+func TestHealthCheckHandlerIgnoresFailedChecks(t *testing.T) {
+	readiness := health.NewReadiness(time.Second, 0)
+	readiness.Register("tmd", func(context.Context) error { return errors.New("tmd is down") })
+	readiness.RegisterDetail("tmdCircuitBreaker", func() any { return "open" })
+
+	rec := httptest.NewRecorder()
+	NewHealthCheckHandler(readiness)(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	var body struct {
+		Status  string         `json:"status"`
+		Details map[string]any `json:"details"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || body.Status != health.StatusOK || body.Details["tmdCircuitBreaker"] != "open" {
+		t.Fatalf("status = %d, body = %+v, want 200 ok with the breaker state", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	NewReadinessHandler(readiness)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readiness status = %d, want 503", rec.Code)
+	}
+}
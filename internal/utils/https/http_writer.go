@@ -4,14 +4,17 @@ import (
 	"encoding/json"
 	"net/http"
 
-	"github.com/olajoe/forecast_weather_api/internal/middlewares"
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
 	"github.com/rs/zerolog"
 )
 
 func WriteError(w http.ResponseWriter, r *http.Request, res ErrorResponse) {
-	logger := middlewares.GetLoggerFromContext(r.Context())
+	logger := logging.Ctx(r.Context())
 	logger.Error().Msg(res.Error())
 
+	if correlationID := logging.CorrelationIDFromContext(r.Context()); correlationID != "" {
+		w.Header().Set(logging.HeaderCorrelationID, correlationID)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(res.Status)
 
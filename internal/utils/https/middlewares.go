@@ -1,6 +1,10 @@
 package https
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"time"
+)
 
 const ContentTypeJson string = "application/json"
 
@@ -13,3 +17,21 @@ func NewMiddlewareContentType(contentType string) func(http.Handler) http.Handle
 		})
 	}
 }
+
+// NewMiddlewareTimeout sets a deadline on the request context so that every
+// call made while handling the request is cancelled once it passes.
+func NewMiddlewareTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package https
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/olajoe/forecast_weather_api/internal/auth"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/olajoe/forecast_weather_api/internal/ratelimit"
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+var errTooManyRequests = errors.New("too many requests")
+
+// NewMiddlewareRateLimit limits requests per authenticated client or API key,
+// or per client IP for anonymous requests, to the tier configured for them.
+// Every response carries the RateLimit-* headers and rejected requests also
+// get Retry-After. Requests are let through when the store fails.
+func NewMiddlewareRateLimit(store ratelimit.Store, cfg config.ClientRateLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, tierName := rateLimitKey(r, cfg)
+			tier := cfg.Tiers[tierName]
+
+			result, err := store.Take(r.Context(), tierName+":"+key, tier.Limit, tier.Window)
+			if err != nil {
+				logger := logging.Ctx(r.Context())
+				logger.Warn().Err(err).Msg("cannot take rate limit")
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reset := strconv.Itoa(int(math.Ceil(result.Reset.Seconds())))
+			header := w.Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderRateLimitReset, reset)
+			header.Set(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%d", tier.Limit, int(tier.Window.Seconds())))
+
+			if !result.Allowed {
+				header.Set(HeaderRetryAfter, reset)
+				WriteError(w, r, NewErrorResponseTooManyRequests(errTooManyRequests))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey returns the key requests are counted under and its tier.
+// Unverified API keys only get their own bucket when they are listed in
+// ClientTiers, otherwise a new key per request would bypass the IP limit.
+// API keys are hashed so they are never written to the store.
+func rateLimitKey(r *http.Request, cfg config.ClientRateLimitConfig) (string, string) {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		return "client:" + identity.Name, clientTier(cfg, identity.Name)
+	}
+
+	if tier, ok := cfg.ClientTiers[r.Header.Get(HeaderAPIKey)]; ok {
+		hash := sha256.Sum256([]byte(r.Header.Get(HeaderAPIKey)))
+		return "key:" + hex.EncodeToString(hash[:]), tier
+	}
+
+	return "ip:" + clientIP(r, cfg.TrustProxy), cfg.DefaultTier
+}
+
+func clientTier(cfg config.ClientRateLimitConfig, client string) string {
+	if tier, ok := cfg.ClientTiers[client]; ok {
+		return tier
+	}
+
+	return cfg.DefaultTier
+}
+
+// clientIP returns the first X-Forwarded-For address when trustProxy is set,
+// otherwise the address of the connection.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); trustProxy && forwardedFor != "" {
+		ip, _, _ := strings.Cut(forwardedFor, ",")
+		return strings.TrimSpace(ip)
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
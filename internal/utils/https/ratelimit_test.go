@@ -0,0 +1,97 @@
+package https
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/olajoe/forecast_weather_api/internal/auth"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/olajoe/forecast_weather_api/internal/ratelimit"
+)
+
+func newTestRateLimitConfig(clientTiers map[string]string) config.ClientRateLimitConfig {
+	return config.ClientRateLimitConfig{
+		Enabled: true,
+		Tiers: map[string]config.RateLimitTier{
+			"default": {Limit: 2, Window: time.Minute},
+			"partner": {Limit: 5, Window: time.Minute},
+		},
+		DefaultTier: "default",
+		ClientTiers: clientTiers,
+	}
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func serve(handler http.Handler, header http.Header) int {
+	r := httptest.NewRequest(http.MethodGet, "/v1/weathers/daily/place", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	return w.Code
+}
+
+func TestRateLimitUnknownAPIKeysShareTheIPLimit(t *testing.T) {
+	cfg := newTestRateLimitConfig(map[string]string{"partner-key": "partner"})
+	handler := NewMiddlewareRateLimit(ratelimit.NewMemoryStore(), cfg)(okHandler())
+
+	statuses := make([]int, 0, 3)
+	for i := range 3 {
+		statuses = append(statuses, serve(handler, http.Header{HeaderAPIKey: {"random-" + strconv.Itoa(i)}}))
+	}
+	if statuses[2] != http.StatusTooManyRequests {
+		t.Fatalf("statuses = %v, want the third request to hit the IP limit", statuses)
+	}
+
+	for i := range 5 {
+		if status := serve(handler, http.Header{HeaderAPIKey: {"partner-key"}}); status != http.StatusOK {
+			t.Fatalf("partner request %d status = %d, want its own tier", i, status)
+		}
+	}
+}
+
+func TestRateLimitCountsFailedAuthentication(t *testing.T) {
+	hash := sha256.Sum256([]byte("valid-key"))
+	store, err := auth.NewAPIKeyStore(&config.Configuration{Auth: config.AuthConfig{
+		APIKeys: "acme:" + hex.EncodeToString(hash[:]) + ":" + auth.ScopeForecastRead,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newTestRateLimitConfig(nil)
+	handler := NewMiddlewareAPIKeyAuth(store)(
+		NewMiddlewareRateLimit(ratelimit.NewMemoryStore(), cfg)(
+			NewMiddlewareRequireAuth()(okHandler()),
+		),
+	)
+
+	wrongKey := http.Header{HeaderAPIKey: {"wrong-key"}}
+	if status := serve(handler, wrongKey); status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", status)
+	}
+	if status := serve(handler, wrongKey); status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", status)
+	}
+	if status := serve(handler, wrongKey); status != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want failed attempts to be rate limited", status)
+	}
+
+	if status := serve(handler, http.Header{HeaderAPIKey: {"valid-key"}}); status != http.StatusOK {
+		t.Fatalf("status = %d, want an authenticated client to get its own bucket", status)
+	}
+}
@@ -7,6 +7,7 @@ import (
 const (
 	HeaderContentTypeKey   = "Content-Type"
 	HeaderAuthorizationKey = "Authorization"
+	HeaderAPIKey           = "X-Api-Key"
 )
 
 type ProxyRequest struct {
@@ -38,6 +39,12 @@ func (e ErrorResponse) Error() string {
 	return e.Message
 }
 
+// WithErrors attaches details such as field level validation errors.
+func (e ErrorResponse) WithErrors(errors interface{}) ErrorResponse {
+	e.Errors = errors
+	return e
+}
+
 func NewErrorResponse(status int, code string, message string) ErrorResponse {
 	return ErrorResponse{
 		Status:  status,
@@ -66,6 +73,10 @@ func NewErrorResponseUnprocessableEntity(err error) ErrorResponse {
 	return NewErrorResponse(http.StatusUnprocessableEntity, "unprocessable-entity", err.Error())
 }
 
+func NewErrorResponseTooManyRequests(err error) ErrorResponse {
+	return NewErrorResponse(http.StatusTooManyRequests, "too-many-requests", err.Error())
+}
+
 func NewErrorResponseInternalServerError(err error) ErrorResponse {
 	return NewErrorResponse(http.StatusInternalServerError, "internal-server-error", err.Error())
 }
@@ -0,0 +1,205 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/th"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	"github.com/gorilla/schema"
+)
+
+const (
+	schemaTypeTag    string = "type"
+	schemaUnknownTag string = "unknown"
+)
+
+// FieldError describes a single invalid request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Translations for the tags used by the handlers, {0} is the field and {1} the tag param.
+// English falls back to the validator defaults for built in tags.
+var translations = map[string]map[string]string{
+	"en": {
+		timeRFC3339Tag:         "{0} must be a valid RFC3339 timestamp",
+		timeRFC3339HourTag:     "{0} must be a valid RFC3339 timestamp at the start of an hour",
+		"required_without_all": "at least one of {0} or [{1}] is required",
+		"excluded_with":        "{0} cannot be combined with [{1}]",
+		isoDateTag:             "{0} must be a valid date in YYYY-MM-DD format",
+		tmdFieldTag:            "{0} must be one of [" + strings.Join(tmdDailyFields, " ") + "]",
+		tmdHourlyFieldTag:      "{0} must be one of [" + strings.Join(tmdHourlyFields, " ") + "]",
+		thaiLatitudeTag:        "{0} must be a latitude within Thailand",
+		thaiLongitudeTag:       "{0} must be a longitude within Thailand",
+		tmdRegionTag:           "{0} must be one of [" + strings.Join(tmdRegions, " ") + "]",
+		schemaTypeTag:          "{0} must be a valid {1}",
+		schemaUnknownTag:       "{0} is not a known field",
+	},
+	"th": {
+		"required":             "จำเป็นต้องระบุ {0}",
+		"required_without_all": "ต้องระบุ {0} หรือ [{1}] อย่างน้อยหนึ่งค่า",
+		"excluded_with":        "ไม่สามารถระบุ {0} ร่วมกับ [{1}]",
+		"oneof":                "{0} ต้องเป็นค่าใดค่าหนึ่งใน [{1}]",
+		"min":                  "{0} ต้องมีค่าอย่างน้อย {1}",
+		"max":                  "{0} ต้องมีค่าไม่เกิน {1}",
+		"gte":                  "{0} ต้องมีค่ามากกว่าหรือเท่ากับ {1}",
+		"lte":                  "{0} ต้องมีค่าน้อยกว่าหรือเท่ากับ {1}",
+		timeRFC3339Tag:         "{0} ต้องเป็นเวลาในรูปแบบ RFC3339",
+		timeRFC3339HourTag:     "{0} ต้องเป็นเวลาในรูปแบบ RFC3339 ที่ตรงต้นชั่วโมง",
+		isoDateTag:             "{0} ต้องเป็นวันที่ในรูปแบบ YYYY-MM-DD",
+		tmdFieldTag:            "{0} ต้องเป็นค่าใดค่าหนึ่งใน [" + strings.Join(tmdDailyFields, " ") + "]",
+		tmdHourlyFieldTag:      "{0} ต้องเป็นค่าใดค่าหนึ่งใน [" + strings.Join(tmdHourlyFields, " ") + "]",
+		thaiLatitudeTag:        "{0} ต้องเป็นละติจูดในประเทศไทย",
+		thaiLongitudeTag:       "{0} ต้องเป็นลองจิจูดในประเทศไทย",
+		tmdRegionTag:           "{0} ต้องเป็นค่าใดค่าหนึ่งใน [" + strings.Join(tmdRegions, " ") + "]",
+		schemaTypeTag:          "{0} ต้องเป็นค่าชนิด {1}",
+		schemaUnknownTag:       "ไม่รู้จักฟิลด์ {0}",
+	},
+}
+
+// NewTranslator registers en and th messages on validate. Thai is the
+// fallback, as for the condition labels of forecasts.
+func NewTranslator(validate *validator.Validate) (*ut.UniversalTranslator, error) {
+	uni := ut.New(th.New(), th.New(), en.New())
+
+	enTrans, _ := uni.GetTranslator("en")
+	if err := enTranslations.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		return nil, err
+	}
+
+	for locale, messages := range translations {
+		trans, _ := uni.GetTranslator(locale)
+		for tag, message := range messages {
+			if err := registerTranslation(validate, trans, tag, message); err != nil {
+				return nil, fmt.Errorf("cannot register %s translation for %s: %w", locale, tag, err)
+			}
+		}
+	}
+
+	return uni, nil
+}
+
+func registerTranslation(validate *validator.Validate, trans ut.Translator, tag string, message string) error {
+	return validate.RegisterTranslation(
+		tag,
+		trans,
+		func(trans ut.Translator) error {
+			return trans.Add(tag, message, true)
+		},
+		func(trans ut.Translator, fe validator.FieldError) string {
+			translated, err := trans.T(tag, fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+
+			return translated
+		},
+	)
+}
+
+// FieldErrors turns validator and schema decoding errors into field errors
+// with messages in the language of trans. Other errors return nil.
+func FieldErrors(err error, trans ut.Translator) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		var structType reflect.Type
+		var invalidStruct invalidStructError
+		if errors.As(err, &invalidStruct) {
+			structType = invalidStruct.structType
+		}
+
+		result := make([]FieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			param := fieldErrorParam(fe, structType)
+
+			message := fe.Translate(trans)
+			if param != fe.Param() {
+				if translated, err := trans.T(fe.Tag(), fe.Field(), param); err == nil {
+					message = translated
+				}
+			}
+
+			result = append(result, FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Param:   param,
+				Message: message,
+			})
+		}
+
+		return result
+	}
+
+	var multiError schema.MultiError
+	if errors.As(err, &multiError) {
+		keys := make([]string, 0, len(multiError))
+		for key := range multiError {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		result := make([]FieldError, 0, len(multiError))
+		for _, key := range keys {
+			result = append(result, schemaFieldError(key, multiError[key], trans))
+		}
+
+		return result
+	}
+
+	return nil
+}
+
+func schemaFieldError(key string, err error, trans ut.Translator) FieldError {
+	rule, param := schemaUnknownTag, ""
+
+	var conversionErr schema.ConversionError
+	var emptyFieldErr schema.EmptyFieldError
+	switch {
+	case errors.As(err, &conversionErr):
+		rule, param = schemaTypeTag, schemaTypeName(conversionErr.Type)
+	case errors.As(err, &emptyFieldErr):
+		rule = "required"
+	}
+
+	message, translateErr := trans.T(rule, key, param)
+	if translateErr != nil {
+		message = err.Error()
+	}
+
+	return FieldError{
+		Field:   key,
+		Rule:    rule,
+		Param:   param,
+		Message: message,
+	}
+}
+
+// schemaTypeName names the expected type of a query param as clients know it,
+// e.g. number rather than float32.
+func schemaTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return t.Kind().String()
+	}
+}
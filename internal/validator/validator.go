@@ -2,17 +2,45 @@ package validator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
 const (
-	timeRFC3339Tag string = "rfc3339"
+	timeRFC3339Tag     string = "rfc3339"
+	timeRFC3339HourTag string = "rfc3339hour"
+	isoDateTag         string = "isodate"
+	tmdFieldTag        string = "tmdfield"
+	tmdHourlyFieldTag  string = "tmdhourlyfield"
+	thaiLatitudeTag    string = "thlat"
+	thaiLongitudeTag   string = "thlon"
+	tmdRegionTag       string = "tmdregion"
 )
 
+// Bounding box of Thailand.
+const (
+	minThaiLatitude  float64 = 5.6
+	maxThaiLatitude  float64 = 20.5
+	minThaiLongitude float64 = 97.3
+	maxThaiLongitude float64 = 105.7
+)
+
+// https://data.tmd.go.th/nwpapi/doc/apidoc/location/forecast_daily.html
+var tmdDailyFields = []string{"tc_max", "tc_min", "rh", "slp", "psfc", "cloudlow", "cloudmed", "cloudhigh", "cond"}
+
+// https://data.tmd.go.th/nwpapi/doc/apidoc/location/forecast_hourly.html
+var tmdHourlyFields = []string{"tc", "rh", "slp", "rain", "ws10m", "wd10m", "cloudlow", "cloudmed", "cloudhigh", "cond"}
+
+// TMD region codes: central, north, northeast, east, south east coast and south west coast.
+var tmdRegions = []string{"C", "N", "NE", "E", "S", "W"}
+
 var rfc3339Validator validator.Func = func(fl validator.FieldLevel) bool {
 	timeStr, ok := fl.Field().Interface().(string)
 	if !ok {
@@ -23,11 +51,186 @@ var rfc3339Validator validator.Func = func(fl validator.FieldLevel) bool {
 	return err == nil
 }
 
+// rfc3339HourValidator accepts RFC3339 timestamps aligned to the start of an
+// hour in absolute time, so 10:00+05:30 (04:30Z) is rejected.
+var rfc3339HourValidator validator.Func = func(fl validator.FieldLevel) bool {
+	timeStr, ok := fl.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	t, err := time.Parse(time.RFC3339, timeStr)
+	if err != nil {
+		return false
+	}
+
+	return t.Equal(t.Truncate(time.Hour))
+}
+
+// isoDateValidator accepts YYYY-MM-DD dates.
+var isoDateValidator validator.Func = func(fl validator.FieldLevel) bool {
+	dateStr, ok := fl.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+
+	_, err := time.Parse(time.DateOnly, dateStr)
+	return err == nil
+}
+
+func newOneOfValidator(values []string) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		value, ok := fl.Field().Interface().(string)
+		if !ok {
+			return false
+		}
+
+		return slices.Contains(values, value)
+	}
+}
+
+func newRangeValidator(min float64, max float64) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		switch fl.Field().Kind() {
+		case reflect.Float32, reflect.Float64:
+			value := fl.Field().Float()
+			return value >= min && value <= max
+		default:
+			return false
+		}
+	}
+}
+
+var customValidations = map[string]validator.Func{
+	timeRFC3339Tag:     rfc3339Validator,
+	timeRFC3339HourTag: rfc3339HourValidator,
+	isoDateTag:         isoDateValidator,
+	tmdFieldTag:        newOneOfValidator(tmdDailyFields),
+	tmdHourlyFieldTag:  newOneOfValidator(tmdHourlyFields),
+	thaiLatitudeTag:    newRangeValidator(minThaiLatitude, maxThaiLatitude),
+	thaiLongitudeTag:   newRangeValidator(minThaiLongitude, maxThaiLongitude),
+	tmdRegionTag:       newOneOfValidator(tmdRegions),
+}
+
+// Tags whose param lists other fields of the struct by their Go name.
+var fieldListTags = []string{
+	"required_with", "required_with_all", "required_without", "required_without_all",
+	"excluded_with", "excluded_with_all", "excluded_without", "excluded_without_all",
+}
+
+func paramName(field reflect.StructField) string {
+	for _, key := range []string{"schema", "json"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// invalidStructError keeps the type of the struct that failed validation so
+// that the fields listed in cross field params can be named as the client
+// sends them.
+type invalidStructError struct {
+	validator.ValidationErrors
+	structType reflect.Type
+}
+
+func (e invalidStructError) Unwrap() error {
+	return e.ValidationErrors
+}
+
+// Struct validates s like validate.Struct, use it when the errors are turned
+// into FieldErrors.
+func Struct(validate *validator.Validate, s any) error {
+	err := validate.Struct(s)
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return invalidStructError{ValidationErrors: validationErrors, structType: reflect.TypeOf(s)}
+	}
+
+	return err
+}
+
+// fieldErrorParam returns the param of fe with the fields of cross field tags
+// named as the client sends them, e.g. "amphoe tambon" for "Amphoe Tambon".
+// They keep their Go name when structType is unknown.
+func fieldErrorParam(fe validator.FieldError, structType reflect.Type) string {
+	if !slices.Contains(fieldListTags, fe.Tag()) {
+		return fe.Param()
+	}
+
+	parent := parentStructType(structType, fe.StructNamespace())
+	if parent == nil {
+		return fe.Param()
+	}
+
+	fields := strings.Fields(fe.Param())
+	for i, name := range fields {
+		if field, ok := parent.FieldByName(name); ok {
+			fields[i] = paramName(field)
+		}
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// parentStructType follows a struct namespace such as Query.Items[0].Lat from
+// structType to the struct holding the last field.
+func parentStructType(structType reflect.Type, structNamespace string) reflect.Type {
+	if structType == nil {
+		return nil
+	}
+
+	parts := strings.Split(structNamespace, ".")
+	t := structType
+	for _, part := range parts[1 : len(parts)-1] {
+		name, _, _ := strings.Cut(part, "[")
+
+		t = indirectType(t)
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			return nil
+		}
+		t = field.Type
+
+		// an element of a slice, array or map, e.g. Items[0]
+		for range strings.Count(part, "[") {
+			t = indirectType(t).Elem()
+		}
+	}
+
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return t
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
 func NewValidator() *validator.Validate {
 	validate := validator.New()
 
-	if err := validate.RegisterValidation(timeRFC3339Tag, rfc3339Validator); err != nil {
-		slog.Log(context.TODO(), slog.LevelError, fmt.Sprintf("cannot register custom RFC3339 validation function: %s", err.Error()))
+	// Report fields by their query param or JSON name, e.g. lat instead of Lat.
+	validate.RegisterTagNameFunc(paramName)
+
+	for tag, fn := range customValidations {
+		if err := validate.RegisterValidation(tag, fn); err != nil {
+			slog.Log(context.TODO(), slog.LevelError, fmt.Sprintf("cannot register custom %s validation function: %s", tag, err.Error()))
+		}
 	}
 
 	return validate
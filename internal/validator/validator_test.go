@@ -0,0 +1,83 @@
+package validator
+
+import "testing"
+
+func TestRFC3339HourValidator(t *testing.T) {
+	validate := NewValidator()
+
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"2026-10-18T10:00:00+07:00", true},
+		{"2026-10-18T03:00:00Z", true},
+		{"2026-10-18T10:00:00-03:00", true},
+		{"2026-10-18T10:00:00+05:30", false},
+		{"2026-10-18T10:00:00+05:45", false},
+		{"2026-10-18T10:30:00+07:00", false},
+		{"2026-10-18T10:00:01+07:00", false},
+		{"2026-10-18T10:00:00.5+07:00", false},
+		{"2026-10-18 10:00:00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			err := validate.Var(tt.value, timeRFC3339HourTag)
+			if valid := err == nil; valid != tt.valid {
+				t.Fatalf("valid = %v, want %v (err = %v)", valid, tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestFieldErrorsNameCrossFieldParams(t *testing.T) {
+	validate := NewValidator()
+	uni, err := NewTranslator(validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type placeQuery struct {
+		Lat      *float32 `json:"lat" validate:"excluded_with=Province Amphoe"`
+		Amphoe   string   `schema:"amphoe"`
+		Province string   `schema:"province" validate:"required_without_all=Lat Amphoe"`
+	}
+	// same Go field names as placeQuery, other param names
+	type districtQuery struct {
+		Lat      *float32 `json:"latitude"`
+		Amphoe   string   `json:"district"`
+		Province string   `json:"province" validate:"required_without_all=Lat Amphoe"`
+	}
+	type batchQuery struct {
+		Items []*districtQuery `json:"items" validate:"dive"`
+	}
+	lat := float32(13.75)
+
+	tests := []struct {
+		name    string
+		locale  string
+		query   any
+		param   string
+		message string
+	}{
+		{"other struct", "en", districtQuery{}, "latitude district", "at least one of province or [latitude district] is required"},
+		{"required", "en", placeQuery{}, "lat amphoe", "at least one of province or [lat amphoe] is required"},
+		{"thai", "th", placeQuery{}, "lat amphoe", "ต้องระบุ province หรือ [lat amphoe] อย่างน้อยหนึ่งค่า"},
+		{"excluded", "en", placeQuery{Lat: &lat, Province: "Bangkok"}, "province amphoe", "lat cannot be combined with [province amphoe]"},
+		{"nested", "en", batchQuery{Items: []*districtQuery{{}}}, "latitude district", "at least one of province or [latitude district] is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trans, _ := uni.GetTranslator(tt.locale)
+
+			fieldErrors := FieldErrors(Struct(validate, tt.query), trans)
+			if len(fieldErrors) != 1 {
+				t.Fatalf("field errors = %+v, want one", fieldErrors)
+			}
+			if fieldErrors[0].Param != tt.param || fieldErrors[0].Message != tt.message {
+				t.Fatalf("field error = %+v, want param %q and message %q", fieldErrors[0], tt.param, tt.message)
+			}
+		})
+	}
+}
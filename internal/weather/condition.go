@@ -0,0 +1,122 @@
+package weather
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// defaultLanguage is used when the caller does not ask for a supported language.
+var defaultLanguage = language.Thai
+
+// Condition labels are loaded from locales/<lang>.json, keyed by TMD cond code.
+// A language is added by dropping a new file in locales.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var conditions = mustNewConditionCatalog(localeFiles)
+
+type conditionCatalog struct {
+	messages map[language.Tag]map[int]string
+	tags     []language.Tag
+	matcher  language.Matcher
+}
+
+func newConditionCatalog(fsys fs.FS) (*conditionCatalog, error) {
+	files, err := fs.Glob(fsys, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	messages := map[language.Tag]map[int]string{}
+	for _, file := range files {
+		tag, err := language.Parse(strings.TrimSuffix(path.Base(file), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale file name %s: %w", file, err)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		var labels map[string]string
+		if err := json.Unmarshal(content, &labels); err != nil {
+			return nil, fmt.Errorf("invalid locale file %s: %w", file, err)
+		}
+
+		messages[tag] = map[int]string{}
+		for code, label := range labels {
+			cond, err := strconv.Atoi(code)
+			if err != nil {
+				return nil, fmt.Errorf("invalid condition code %q in %s: %w", code, file, err)
+			}
+
+			messages[tag][cond] = label
+		}
+	}
+
+	if _, ok := messages[defaultLanguage]; !ok {
+		return nil, fmt.Errorf("missing locale file for default language %s", defaultLanguage)
+	}
+
+	// The matcher falls back to the first tag, so the default language goes first.
+	tags := []language.Tag{defaultLanguage}
+	for tag := range messages {
+		if tag != defaultLanguage {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags[1:], func(i, j int) bool {
+		return tags[i+1].String() < tags[j+1].String()
+	})
+
+	return &conditionCatalog{
+		messages: messages,
+		tags:     tags,
+		matcher:  language.NewMatcher(tags),
+	}, nil
+}
+
+func mustNewConditionCatalog(fsys fs.FS) *conditionCatalog {
+	catalog, err := newConditionCatalog(fsys)
+	if err != nil {
+		panic(err)
+	}
+
+	return catalog
+}
+
+// Match picks the best supported language from the lang query param and then
+// the Accept-Language header.
+func (c *conditionCatalog) Match(lang string, acceptLanguage string) language.Tag {
+	var preferred []language.Tag
+	if tag, err := language.Parse(lang); err == nil {
+		preferred = append(preferred, tag)
+	}
+	if tags, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil {
+		preferred = append(preferred, tags...)
+	}
+
+	_, index, _ := c.matcher.Match(preferred...)
+
+	return c.tags[index]
+}
+
+// Text returns nil for condition codes missing from the catalog.
+func (c *conditionCatalog) Text(lang language.Tag, condition float64) *string {
+	label, ok := c.messages[lang][int(condition)]
+	if !ok {
+		return nil
+	}
+
+	return &label
+}
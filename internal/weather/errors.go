@@ -0,0 +1,128 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/imroc/req/v3"
+	"github.com/olajoe/forecast_weather_api/internal/utils/https"
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
+)
+
+var (
+	ErrInvalidLocation      = errors.New("invalid location")
+	ErrNotFound             = errors.New("forecast not found")
+	ErrUpstreamUnauthorized = errors.New("upstream unauthorized")
+	ErrUpstreamRateLimited  = errors.New("upstream rate limited")
+	ErrUpstreamUnavailable  = errors.New("upstream unavailable")
+	ErrRateLimited          = errors.New("too many upstream calls")
+)
+
+// statusClientClosedRequest is the non-standard status, as used by nginx, for
+// requests the client gave up on before the response was written.
+const statusClientClosedRequest = 499
+
+// errorResponses maps domain errors to responses with a stable code.
+// The first matching error wins.
+var errorResponses = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{ErrInvalidLocation, http.StatusBadRequest, "invalid-location"},
+	{ErrNotFound, http.StatusNotFound, "not-found"},
+	{ErrUpstreamUnauthorized, http.StatusBadGateway, "upstream-unauthorized"},
+	{ErrUpstreamRateLimited, http.StatusTooManyRequests, "upstream-rate-limited"},
+	{ErrUpstreamUnavailable, http.StatusServiceUnavailable, "upstream-unavailable"},
+	{ErrRateLimited, http.StatusTooManyRequests, "rate-limited"},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, "gateway-timeout"},
+	{context.Canceled, statusClientClosedRequest, "client-closed-request"},
+}
+
+// UpstreamError is an error response from TMD. It unwraps to one of the
+// domain errors above and keeps the raw response for logging.
+type UpstreamError struct {
+	Err        error
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("TMD responded with status %d: %s", e.StatusCode, e.Err)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+func newUpstreamError(resp *req.Response) *UpstreamError {
+	return &UpstreamError{
+		Err:        mapUpstreamStatusToError(resp.StatusCode),
+		URL:        resp.Request.RawURL,
+		StatusCode: resp.StatusCode,
+		Body:       resp.String(),
+	}
+}
+
+func mapUpstreamStatusToError(statusCode int) error {
+	switch {
+	case statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity:
+		return ErrInvalidLocation
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrUpstreamUnauthorized
+	case statusCode == http.StatusTooManyRequests:
+		return ErrUpstreamRateLimited
+	default:
+		return ErrUpstreamUnavailable
+	}
+}
+
+// upstreamOutcome labels the result of a TMD call with the code of its domain
+// error, e.g. success, not-found or upstream-unavailable.
+func upstreamOutcome(err error) string {
+	if err == nil {
+		return "success"
+	}
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+
+	for _, errorResponse := range errorResponses {
+		if errors.Is(err, errorResponse.err) {
+			return errorResponse.code
+		}
+	}
+
+	return "error"
+}
+
+func writeUsecaseError(w http.ResponseWriter, r *http.Request, err error) {
+	https.WriteError(w, r, mapUsecaseError(r.Context(), err))
+}
+
+// mapUsecaseError logs the TMD response behind err, if any, and returns the
+// response of the first matching domain error.
+func mapUsecaseError(ctx context.Context, err error) https.ErrorResponse {
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		logger := logging.Ctx(ctx)
+		logger.Error().
+			Str("upstreamUrl", upstreamErr.URL).
+			Int("upstreamStatus", upstreamErr.StatusCode).
+			Str("upstreamBody", upstreamErr.Body).
+			Msg("TMD error response")
+	}
+
+	for _, errorResponse := range errorResponses {
+		if errors.Is(err, errorResponse.err) {
+			return https.NewErrorResponse(errorResponse.status, errorResponse.code, errorResponse.err.Error())
+		}
+	}
+
+	return https.NewErrorResponseInternalServerError(err)
+}
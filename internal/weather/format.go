@@ -0,0 +1,227 @@
+package weather
+
+import (
+	"fmt"
+
+	"github.com/olajoe/forecast_weather_api/internal/utils"
+	"github.com/olajoe/forecast_weather_api/internal/utils/https"
+	apiv1 "github.com/olajoe/forecast_weather_api/pkg/api/v1"
+	"golang.org/x/text/language"
+)
+
+// metricUnits are the units TMD reports forecast values in.
+var metricUnits = apiv1.Units{
+	Tc:        "°C",
+	TcMin:     "°C",
+	TcMax:     "°C",
+	Rh:        "%",
+	Slp:       "hPa",
+	Psfc:      "Pa",
+	Rain:      "mm",
+	Ws10m:     "m/s",
+	Wd10m:     "°",
+	CloudLow:  "%",
+	CloudMed:  "%",
+	CloudHigh: "%",
+	SwDown:    "W/m^2",
+}
+
+// forecastOutputQuery holds the query params shared by every forecast endpoint
+// that control how values are rendered.
+type forecastOutputQuery struct {
+	Format string `schema:"format" validate:"omitempty,oneof=numeric display"`   // numeric or display, default display
+	Units  string `schema:"units" validate:"omitempty,oneof=metric imperial si"` // metric, imperial or si, default metric
+	Lang   string `schema:"lang"`                                                // overrides Accept-Language, default th
+}
+
+// forecastRenderer converts usecase results into the unit system, language
+// and format requested by the caller.
+type forecastRenderer struct {
+	format    string
+	converter unitConverter
+	lang      language.Tag
+}
+
+func newForecastRenderer(output forecastOutputQuery, acceptLanguage string) forecastRenderer {
+	return forecastRenderer{
+		format:    output.Format,
+		converter: getUnitConverter(output.Units),
+		lang:      conditions.Match(output.Lang, acceptLanguage),
+	}
+}
+
+func (f forecastRenderer) isNumeric() bool {
+	return f.format == apiv1.FormatNumeric
+}
+
+func (f forecastRenderer) units() *apiv1.Units {
+	if !f.isNumeric() {
+		return nil
+	}
+
+	units := f.converter.units
+	return &units
+}
+
+func (f forecastRenderer) numeric(result []apiv1.LocationForecast[apiv1.ForecastValues]) []apiv1.LocationForecast[apiv1.ForecastValues] {
+	return convertForecastValues(result, func(values apiv1.ForecastValues) apiv1.ForecastValues {
+		values = f.converter.convert(values)
+		if values.Cond != nil {
+			values.CondText = conditions.Text(f.lang, *values.Cond)
+		}
+
+		return values
+	})
+}
+
+func (f forecastRenderer) display(result []apiv1.LocationForecast[apiv1.ForecastValues]) []apiv1.LocationForecast[apiv1.DisplayForecastValues] {
+	result = f.numeric(result)
+
+	data := make([]apiv1.LocationForecast[apiv1.DisplayForecastValues], 0, len(result))
+	for _, locationForecast := range result {
+		forecasts := make([]apiv1.Forecast[apiv1.DisplayForecastValues], 0, len(locationForecast.Forecasts))
+		for _, forecast := range locationForecast.Forecasts {
+			forecasts = append(forecasts, apiv1.Forecast[apiv1.DisplayForecastValues]{
+				Time: forecast.Time,
+				Data: formatForecastValues(forecast.Data, f.converter.units),
+			})
+		}
+
+		data = append(data, apiv1.LocationForecast[apiv1.DisplayForecastValues]{
+			Location:  locationForecast.Location,
+			Forecasts: forecasts,
+		})
+	}
+
+	return data
+}
+
+// buildForecastResponse renders the usecase result as raw numbers with a units
+// block, or as display strings unless format is apiv1.FormatNumeric.
+func buildForecastResponse(
+	result []apiv1.LocationForecast[apiv1.ForecastValues],
+	output forecastOutputQuery,
+	acceptLanguage string,
+) any {
+	renderer := newForecastRenderer(output, acceptLanguage)
+
+	if renderer.isNumeric() {
+		return apiv1.ForecastResponse[apiv1.ForecastValues]{
+			Units: renderer.units(),
+			Data:  renderer.numeric(result),
+		}
+	}
+
+	return apiv1.ForecastResponse[apiv1.DisplayForecastValues]{
+		Data: renderer.display(result),
+	}
+}
+
+// batchItemResult is the usecase result of one batch item, or the error
+// response when the item was invalid or failed.
+type batchItemResult struct {
+	result []apiv1.LocationForecast[apiv1.ForecastValues]
+	err    *https.ErrorResponse
+}
+
+// buildBatchResponse renders every batch item like buildForecastResponse and
+// keeps failed items in place with their error.
+func buildBatchResponse(
+	items []batchItemResult,
+	output forecastOutputQuery,
+	acceptLanguage string,
+) any {
+	renderer := newForecastRenderer(output, acceptLanguage)
+
+	if renderer.isNumeric() {
+		return apiv1.BatchResponse[apiv1.ForecastValues]{
+			Units: renderer.units(),
+			Data:  buildBatchItems(items, renderer.numeric),
+		}
+	}
+
+	return apiv1.BatchResponse[apiv1.DisplayForecastValues]{
+		Data: buildBatchItems(items, renderer.display),
+	}
+}
+
+func buildBatchItems[V apiv1.Values](
+	items []batchItemResult,
+	render func([]apiv1.LocationForecast[apiv1.ForecastValues]) []apiv1.LocationForecast[V],
+) []apiv1.BatchItem[V] {
+	data := make([]apiv1.BatchItem[V], 0, len(items))
+	for _, item := range items {
+		if item.err != nil {
+			data = append(data, apiv1.BatchItem[V]{
+				Error: &apiv1.Error{
+					Status:  item.err.Status,
+					Code:    item.err.Code,
+					Message: item.err.Message,
+					Errors:  item.err.Errors,
+				},
+			})
+			continue
+		}
+
+		data = append(data, apiv1.BatchItem[V]{Data: render(item.result)})
+	}
+
+	return data
+}
+
+func convertForecastValues(
+	result []apiv1.LocationForecast[apiv1.ForecastValues],
+	convert func(apiv1.ForecastValues) apiv1.ForecastValues,
+) []apiv1.LocationForecast[apiv1.ForecastValues] {
+	converted := make([]apiv1.LocationForecast[apiv1.ForecastValues], 0, len(result))
+	for _, locationForecast := range result {
+		forecasts := make([]apiv1.Forecast[apiv1.ForecastValues], 0, len(locationForecast.Forecasts))
+		for _, forecast := range locationForecast.Forecasts {
+			forecasts = append(forecasts, apiv1.Forecast[apiv1.ForecastValues]{
+				Time: forecast.Time,
+				Data: convert(forecast.Data),
+			})
+		}
+
+		converted = append(converted, apiv1.LocationForecast[apiv1.ForecastValues]{
+			Location:  locationForecast.Location,
+			Forecasts: forecasts,
+		})
+	}
+
+	return converted
+}
+
+func formatForecastValues(values apiv1.ForecastValues, units apiv1.Units) apiv1.DisplayForecastValues {
+	result := apiv1.DisplayForecastValues{
+		Tc:        formatForecastValue(values.Tc, units.Tc),
+		TcMin:     formatForecastValue(values.TcMin, units.TcMin),
+		TcMax:     formatForecastValue(values.TcMax, units.TcMax),
+		Rh:        formatForecastValue(values.Rh, units.Rh),
+		Slp:       formatForecastValue(values.Slp, units.Slp),
+		Psfc:      formatForecastValue(values.Psfc, units.Psfc),
+		Rain:      formatForecastValue(values.Rain, units.Rain),
+		Ws10m:     formatForecastValue(values.Ws10m, units.Ws10m),
+		Wd10m:     formatForecastValue(values.Wd10m, units.Wd10m),
+		CloudLow:  formatForecastValue(values.CloudLow, units.CloudLow),
+		CloudMed:  formatForecastValue(values.CloudMed, units.CloudMed),
+		CloudHigh: formatForecastValue(values.CloudHigh, units.CloudHigh),
+		SwDown:    formatForecastValue(values.SwDown, units.SwDown),
+	}
+
+	if values.Cond != nil {
+		condCode := int(*values.Cond)
+		result.Cond = values.CondText
+		result.CondCode = &condCode
+	}
+
+	return result
+}
+
+func formatForecastValue(value *float64, unit string) *string {
+	if value == nil {
+		return nil
+	}
+
+	return utils.StrToPointer(fmt.Sprintf("%v %s", *value, unit))
+}
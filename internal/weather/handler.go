@@ -1,29 +1,50 @@
 package weather
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/schema"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/olajoe/forecast_weather_api/internal/utils"
 	"github.com/olajoe/forecast_weather_api/internal/utils/https"
+	internalValidator "github.com/olajoe/forecast_weather_api/internal/validator"
 	"github.com/olajoe/forecast_weather_api/pkg/logging"
 )
 
+// maxBatchBodyBytes bounds the body of batch requests.
+const maxBatchBodyBytes = 1 << 20
+
+var (
+	errInvalidParameters = errors.New("invalid request parameters")
+	errEmptyBatch        = errors.New("batch must contain at least one query")
+)
+
 type WeatherHandler struct {
 	validate       *validator.Validate
+	translator     *ut.UniversalTranslator
 	schemaDecoder  *schema.Decoder
 	weatherUsecase WeatherUsecase
+	batchMaxSize   int
 }
 
 func NewWeatherHandler(
 	validate *validator.Validate,
+	translator *ut.UniversalTranslator,
 	schemaDecoder *schema.Decoder,
 	weatherUsecase WeatherUsecase,
+	cfg *config.Configuration,
 ) *WeatherHandler {
 	return &WeatherHandler{
 		validate:       validate,
+		translator:     translator,
 		schemaDecoder:  schemaDecoder,
 		weatherUsecase: weatherUsecase,
+		batchMaxSize:   cfg.Batch.MaxSize,
 	}
 }
 
@@ -32,11 +53,11 @@ func (h *WeatherHandler) GetWeatherForecastDailyByCoordinates(w http.ResponseWri
 	logger := logging.Ctx(ctx)
 
 	var queries struct {
-		Lat      float32  `schema:"lat,required"`
-		Lon      float32  `schema:"lon,required"`
-		Date     string   `schema:"date"`     // YYYY-MM-DD
-		Duration int      `schema:"duration"` // default 1 days, max 126 days
-		Fields   []string `schema:"fields"`   // fields=tc_max,tc_min,rh,slp,psfc,cloudlow,cloudmed,cloudhigh,cond
+		Lat      float32  `schema:"lat,required" validate:"thlat"`
+		Lon      float32  `schema:"lon,required" validate:"thlon"`
+		Date     string   `schema:"date" validate:"omitempty,isodate"`           // YYYY-MM-DD
+		Duration int      `schema:"duration" validate:"omitempty,min=1,max=126"` // default 1 days, max 126 days
+		Fields   []string `schema:"fields" validate:"dive,tmdfield"`             // fields=tc_max,tc_min,rh,slp,psfc,cloudlow,cloudmed,cloudhigh,cond
 		/* fields // https://data.tmd.go.th/nwpapi/doc/apidoc/location/forecast_daily.html
 		tc_max
 		tc_min
@@ -48,18 +69,25 @@ func (h *WeatherHandler) GetWeatherForecastDailyByCoordinates(w http.ResponseWri
 		cloudhigh
 		cond
 		*/
+
+		forecastOutputQuery
 	}
 
 	if err := h.schemaDecoder.Decode(&queries, r.URL.Query()); err != nil {
-		https.WriteError(w, r, https.NewErrorResponseBadRequest(err))
+		h.writeValidationError(w, r, err)
 		return
 	}
+	queries.Fields = splitFields(queries.Fields)
 
-	if err := h.validate.Struct(queries); err != nil {
-		https.WriteError(w, r, https.NewErrorResponseBadRequest(err))
+	if err := internalValidator.Struct(h.validate, queries); err != nil {
+		h.writeValidationError(w, r, err)
 		return
 	}
 
+	if queries.Duration == 0 {
+		queries.Duration = defaultDuration
+	}
+
 	queriesData := buildGetWeatherDailyCordinatesQuery(
 		queries.Lat,
 		queries.Lon,
@@ -68,15 +96,13 @@ func (h *WeatherHandler) GetWeatherForecastDailyByCoordinates(w http.ResponseWri
 		queries.Fields,
 	)
 
-	result, err := h.weatherUsecase.GetWeatherDailyByCoordinates(queriesData)
+	result, err := h.weatherUsecase.GetWeatherDailyByCoordinates(ctx, queriesData)
 	if err != nil {
-		https.WriteError(w, r, https.NewErrorResponseInternalServerError(err))
+		writeUsecaseError(w, r, err)
 		return
 	}
 
-	https.WriteResponse(w, logger, http.StatusOK, map[string]interface{}{
-		"data": result,
-	})
+	https.WriteResponse(w, logger, http.StatusOK, buildForecastResponse(result, queries.forecastOutputQuery, r.Header.Get("Accept-Language")))
 }
 
 func (h *WeatherHandler) GetWeatherForecastDailyByPlace(w http.ResponseWriter, r *http.Request) {
@@ -86,12 +112,12 @@ func (h *WeatherHandler) GetWeatherForecastDailyByPlace(w http.ResponseWriter, r
 	var queries struct {
 		Tambon   string `schema:"tambon"`
 		Amphoe   string `schema:"amphoe"`
-		Province string `schema:"province"`
+		Province string `schema:"province" validate:"required_without_all=Amphoe Tambon"`
 		SubArea  bool   `schema:"subarea"`
 
-		Date     string   `schema:"date"`     // YYYY-MM-DD
-		Duration int      `schema:"duration"` // default 1 days, max 126 days
-		Fields   []string `schema:"fields"`   // fields=tc_max,tc_min,rh,slp,psfc,cloudlow,cloudmed,cloudhigh,cond
+		Date     string   `schema:"date" validate:"omitempty,isodate"`           // YYYY-MM-DD
+		Duration int      `schema:"duration" validate:"omitempty,min=1,max=126"` // default 1 days, max 126 days
+		Fields   []string `schema:"fields" validate:"dive,tmdfield"`             // fields=tc_max,tc_min,rh,slp,psfc,cloudlow,cloudmed,cloudhigh,cond
 		/* fields // https://data.tmd.go.th/nwpapi/doc/apidoc/location/forecast_daily.html
 		tc_max
 		tc_min
@@ -103,18 +129,25 @@ func (h *WeatherHandler) GetWeatherForecastDailyByPlace(w http.ResponseWriter, r
 		cloudhigh
 		cond
 		*/
+
+		forecastOutputQuery
 	}
 
 	if err := h.schemaDecoder.Decode(&queries, r.URL.Query()); err != nil {
-		https.WriteError(w, r, https.NewErrorResponseBadRequest(err))
+		h.writeValidationError(w, r, err)
 		return
 	}
+	queries.Fields = splitFields(queries.Fields)
 
-	if err := h.validate.Struct(queries); err != nil {
-		https.WriteError(w, r, https.NewErrorResponseBadRequest(err))
+	if err := internalValidator.Struct(h.validate, queries); err != nil {
+		h.writeValidationError(w, r, err)
 		return
 	}
 
+	if queries.Duration == 0 {
+		queries.Duration = defaultDuration
+	}
+
 	queriesData := buildGetWeatherDailyPlaceQuery(
 		queries.Province,
 		queries.Amphoe,
@@ -125,14 +158,327 @@ func (h *WeatherHandler) GetWeatherForecastDailyByPlace(w http.ResponseWriter, r
 		queries.Fields,
 	)
 
-	result, err := h.weatherUsecase.GetWeatherDailyByPlace(queriesData)
+	result, err := h.weatherUsecase.GetWeatherDailyByPlace(ctx, queriesData)
+	if err != nil {
+		writeUsecaseError(w, r, err)
+		return
+	}
+
+	https.WriteResponse(w, logger, http.StatusOK, buildForecastResponse(result, queries.forecastOutputQuery, r.Header.Get("Accept-Language")))
+
+}
+
+func (h *WeatherHandler) GetWeatherForecastDailyByRegion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.Ctx(ctx)
+
+	var queries struct {
+		Region string `schema:"region,required" validate:"tmdregion"` // C, N, NE, E, S or W
+
+		Date     string   `schema:"date" validate:"omitempty,isodate"`           // YYYY-MM-DD
+		Duration int      `schema:"duration" validate:"omitempty,min=1,max=126"` // default 1 days, max 126 days
+		Fields   []string `schema:"fields" validate:"dive,tmdfield"`             // fields=tc_max,tc_min,rh,slp,psfc,cloudlow,cloudmed,cloudhigh,cond
+
+		forecastOutputQuery
+	}
+
+	if err := h.schemaDecoder.Decode(&queries, r.URL.Query()); err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+	queries.Fields = splitFields(queries.Fields)
+
+	if err := internalValidator.Struct(h.validate, queries); err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+
+	if queries.Duration == 0 {
+		queries.Duration = defaultDuration
+	}
+
+	queriesData := buildGetWeatherDailyRegionQuery(
+		queries.Region,
+		queries.Date,
+		queries.Duration,
+		queries.Fields,
+	)
+
+	result, err := h.weatherUsecase.GetWeatherDailyByRegion(ctx, queriesData)
+	if err != nil {
+		writeUsecaseError(w, r, err)
+		return
+	}
+
+	https.WriteResponse(w, logger, http.StatusOK, buildForecastResponse(result, queries.forecastOutputQuery, r.Header.Get("Accept-Language")))
+}
+
+func (h *WeatherHandler) GetWeatherForecastHourlyByCoordinates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.Ctx(ctx)
+
+	var queries struct {
+		Lat       float32  `schema:"lat,required" validate:"thlat"`
+		Lon       float32  `schema:"lon,required" validate:"thlon"`
+		StartTime string   `schema:"starttime,required" validate:"rfc3339hour"`  // RFC3339 aligned to the hour
+		Duration  int      `schema:"duration" validate:"omitempty,min=1,max=48"` // default 1 hours, max 48 hours
+		Fields    []string `schema:"fields" validate:"dive,tmdhourlyfield"`      // fields=tc,rh,slp,rain,ws10m,wd10m,cloudlow,cloudmed,cloudhigh,cond
+		/* fields // https://data.tmd.go.th/nwpapi/doc/apidoc/location/forecast_hourly.html
+		tc
+		rh
+		slp
+		rain
+		ws10m
+		wd10m
+		cloudlow
+		cloudmed
+		cloudhigh
+		cond
+		*/
+
+		forecastOutputQuery
+	}
+
+	if err := h.schemaDecoder.Decode(&queries, r.URL.Query()); err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+	queries.Fields = splitFields(queries.Fields)
+
+	if err := internalValidator.Struct(h.validate, queries); err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+
+	if queries.Duration == 0 {
+		queries.Duration = defaultDuration
+	}
+
+	startTime, err := time.Parse(time.RFC3339, queries.StartTime)
+	if err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+
+	queriesData := buildGetWeatherHourlyCoordinatesQuery(
+		queries.Lat,
+		queries.Lon,
+		startTime,
+		queries.Duration,
+		queries.Fields,
+	)
+
+	result, err := h.weatherUsecase.GetWeatherHourlyByCoordinates(ctx, queriesData)
+	if err != nil {
+		writeUsecaseError(w, r, err)
+		return
+	}
+
+	https.WriteResponse(w, logger, http.StatusOK, buildForecastResponse(result, queries.forecastOutputQuery, r.Header.Get("Accept-Language")))
+}
+
+func (h *WeatherHandler) GetWeatherForecastHourlyByPlace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.Ctx(ctx)
+
+	var queries struct {
+		Tambon   string `schema:"tambon"`
+		Amphoe   string `schema:"amphoe"`
+		Province string `schema:"province" validate:"required_without_all=Amphoe Tambon"`
+		SubArea  bool   `schema:"subarea"`
+
+		StartTime string   `schema:"starttime,required" validate:"rfc3339hour"`  // RFC3339 aligned to the hour
+		Duration  int      `schema:"duration" validate:"omitempty,min=1,max=48"` // default 1 hours, max 48 hours
+		Fields    []string `schema:"fields" validate:"dive,tmdhourlyfield"`      // fields=tc,rh,slp,rain,ws10m,wd10m,cloudlow,cloudmed,cloudhigh,cond
+		/* fields // https://data.tmd.go.th/nwpapi/doc/apidoc/location/forecast_hourly.html
+		tc
+		rh
+		slp
+		rain
+		ws10m
+		wd10m
+		cloudlow
+		cloudmed
+		cloudhigh
+		cond
+		*/
+
+		forecastOutputQuery
+	}
+
+	if err := h.schemaDecoder.Decode(&queries, r.URL.Query()); err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+	queries.Fields = splitFields(queries.Fields)
+
+	if err := internalValidator.Struct(h.validate, queries); err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+
+	if queries.Duration == 0 {
+		queries.Duration = defaultDuration
+	}
+
+	startTime, err := time.Parse(time.RFC3339, queries.StartTime)
+	if err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+
+	queriesData := buildGetWeatherHourlyPlaceQuery(
+		queries.Province,
+		queries.Amphoe,
+		queries.Tambon,
+		queries.SubArea,
+		startTime,
+		queries.Duration,
+		queries.Fields,
+	)
+
+	result, err := h.weatherUsecase.GetWeatherHourlyByPlace(ctx, queriesData)
 	if err != nil {
-		https.WriteError(w, r, https.NewErrorResponseInternalServerError(err))
+		writeUsecaseError(w, r, err)
 		return
 	}
 
-	https.WriteResponse(w, logger, http.StatusOK, map[string]interface{}{
-		"data": result,
-	})
+	https.WriteResponse(w, logger, http.StatusOK, buildForecastResponse(result, queries.forecastOutputQuery, r.Header.Get("Accept-Language")))
+}
+
+// GetWeatherForecastDailyBatch takes a JSON array of coordinates or place
+// queries and answers with one item per query in the same order. Invalid or
+// failed queries get an error item instead of failing the whole batch.
+func (h *WeatherHandler) GetWeatherForecastDailyBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.Ctx(ctx)
+
+	var queries struct {
+		forecastOutputQuery
+	}
+
+	if err := h.schemaDecoder.Decode(&queries, r.URL.Query()); err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+
+	if err := internalValidator.Struct(h.validate, queries); err != nil {
+		h.writeValidationError(w, r, err)
+		return
+	}
+
+	var body []struct {
+		// coordinates
+		Lat *float32 `json:"lat" validate:"required_without_all=Province Amphoe Tambon,excluded_with=Province Amphoe Tambon,omitempty,thlat"`
+		Lon *float32 `json:"lon" validate:"required_without_all=Province Amphoe Tambon,excluded_with=Province Amphoe Tambon,omitempty,thlon"`
+
+		// place
+		Tambon   string `json:"tambon"`
+		Amphoe   string `json:"amphoe"`
+		Province string `json:"province" validate:"required_without_all=Lat Amphoe Tambon"`
+		SubArea  bool   `json:"subarea"`
+
+		Date     string   `json:"date" validate:"omitempty,isodate"`           // YYYY-MM-DD
+		Duration int      `json:"duration" validate:"omitempty,min=1,max=126"` // default 1 days, max 126 days
+		Fields   []string `json:"fields" validate:"dive,tmdfield"`             // ["tc_max","tc_min",...] as in /daily/coordinates
+	}
+
+	if err := utils.ParseJsonBody(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes), &body); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			https.WriteError(w, r, https.NewErrorResponse(
+				http.StatusRequestEntityTooLarge,
+				"body-too-large",
+				fmt.Sprintf("request body must be at most %d bytes", maxBytesErr.Limit),
+			))
+			return
+		}
+
+		https.WriteError(w, r, https.NewErrorResponseBadRequest(err))
+		return
+	}
+
+	if len(body) == 0 {
+		https.WriteError(w, r, https.NewErrorResponseBadRequest(errEmptyBatch))
+		return
+	}
+
+	if len(body) > h.batchMaxSize {
+		https.WriteError(w, r, https.NewErrorResponse(
+			http.StatusRequestEntityTooLarge,
+			"batch-too-large",
+			fmt.Sprintf("batch must contain at most %d queries", h.batchMaxSize),
+		))
+		return
+	}
+
+	items := make([]batchItemResult, len(body))
+	queriesData := make([]GetWeatherDailyQuery, 0, len(body))
+	indexes := make([]int, 0, len(body))
+	for i, item := range body {
+		item.Fields = splitFields(item.Fields)
+
+		if err := internalValidator.Struct(h.validate, item); err != nil {
+			res := h.validationErrorResponse(r, err)
+			items[i].err = &res
+			continue
+		}
+
+		if item.Duration == 0 {
+			item.Duration = defaultDuration
+		}
+
+		if item.Lat != nil {
+			queriesData = append(queriesData, buildGetWeatherDailyCordinatesQuery(
+				*item.Lat,
+				*item.Lon,
+				item.Date,
+				item.Duration,
+				item.Fields,
+			))
+		} else {
+			queriesData = append(queriesData, buildGetWeatherDailyPlaceQuery(
+				item.Province,
+				item.Amphoe,
+				item.Tambon,
+				item.SubArea,
+				item.Date,
+				item.Duration,
+				item.Fields,
+			))
+		}
+		indexes = append(indexes, i)
+	}
+
+	for j, result := range h.weatherUsecase.GetWeatherDailyBatch(ctx, queriesData) {
+		i := indexes[j]
+		if result.Err != nil {
+			res := mapUsecaseError(ctx, result.Err)
+			items[i].err = &res
+			continue
+		}
+
+		items[i].result = result.Result
+	}
+
+	https.WriteResponse(w, logger, http.StatusOK, buildBatchResponse(items, queries.forecastOutputQuery, r.Header.Get("Accept-Language")))
+}
+
+// writeValidationError lists every invalid field in the language picked from
+// the lang query param or the Accept-Language header.
+func (h *WeatherHandler) writeValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	https.WriteError(w, r, h.validationErrorResponse(r, err))
+}
+
+func (h *WeatherHandler) validationErrorResponse(r *http.Request, err error) https.ErrorResponse {
+	// Same language as the condition labels of a successful response
+	lang := conditions.Match(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+	trans, _ := h.translator.GetTranslator(lang.String())
+
+	fieldErrors := internalValidator.FieldErrors(err, trans)
+	if fieldErrors == nil {
+		return https.NewErrorResponseBadRequest(err)
+	}
 
+	return https.NewErrorResponseBadRequest(errInvalidParameters).WithErrors(fieldErrors)
 }
@@ -0,0 +1,112 @@
+package weather
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/schema"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	internalValidator "github.com/olajoe/forecast_weather_api/internal/validator"
+)
+
+func TestValidationErrorLanguage(t *testing.T) {
+	validate := internalValidator.NewValidator()
+	translator, err := internalValidator.NewTranslator(validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Configuration{}
+	handler := NewWeatherHandler(validate, translator, schema.NewDecoder(), NewWeatherUsecase(&fakeWeatherRepository{}, cfg), cfg)
+
+	tests := []struct {
+		name           string
+		query          string
+		acceptLanguage string
+		want           internalValidator.FieldError
+	}{
+		{
+			name:  "thai by default",
+			query: "lat=abc&lon=100.5",
+			want:  internalValidator.FieldError{Field: "lat", Rule: "type", Param: "number", Message: "lat ต้องเป็นค่าชนิด number"},
+		},
+		{
+			name:           "accept language",
+			query:          "lat=abc&lon=100.5",
+			acceptLanguage: "en-US,en;q=0.9",
+			want:           internalValidator.FieldError{Field: "lat", Rule: "type", Param: "number", Message: "lat must be a valid number"},
+		},
+		{
+			name:           "lang overrides accept language",
+			query:          "lat=13.75&lon=100.5&duration=1.5&lang=en",
+			acceptLanguage: "th",
+			want:           internalValidator.FieldError{Field: "duration", Rule: "type", Param: "integer", Message: "duration must be a valid integer"},
+		},
+		{
+			name:           "unsupported language",
+			query:          "lat=30&lon=100.5",
+			acceptLanguage: "ja",
+			want:           internalValidator.FieldError{Field: "lat", Rule: "thlat", Message: "lat ต้องเป็นละติจูดในประเทศไทย"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/weathers/daily/at?"+tt.query, nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.GetWeatherForecastDailyByCoordinates(rec, r)
+
+			var response struct {
+				Errors []internalValidator.FieldError `json:"errors"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != http.StatusBadRequest || len(response.Errors) != 1 || response.Errors[0] != tt.want {
+				t.Fatalf("status = %d, errors = %+v, want 400 with %+v", rec.Code, response.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyBatchRequestTooLarge(t *testing.T) {
+	validate := internalValidator.NewValidator()
+	translator, err := internalValidator.NewTranslator(validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Configuration{Batch: config.BatchConfig{MaxSize: 2, Concurrency: 1}}
+	handler := NewWeatherHandler(validate, translator, schema.NewDecoder(), NewWeatherUsecase(&fakeWeatherRepository{}, cfg), cfg)
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"body over the byte limit", `[{"province":"` + strings.Repeat("a", maxBatchBodyBytes) + `"}]`, "body-too-large"},
+		{"too many queries", `[{"province":"a"},{"province":"b"},{"province":"c"}]`, "batch-too-large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.GetWeatherForecastDailyBatch(rec, httptest.NewRequest(http.MethodPost, "/v1/weathers/daily/batch", strings.NewReader(tt.body)))
+
+			var response struct {
+				Code string `json:"code"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != http.StatusRequestEntityTooLarge || response.Code != tt.code {
+				t.Fatalf("status = %d, code = %q, want 413 %s", rec.Code, response.Code, tt.code)
+			}
+		})
+	}
+}
@@ -3,8 +3,15 @@ package weather
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
+// defaultDuration is the number of days or hours forecast when duration is not set.
+const defaultDuration = 1
+
+// tmdLocation is the timezone TMD uses to interpret date and hour query params.
+var tmdLocation = time.FixedZone("ICT", 7*60*60)
+
 type Location struct {
 	Province *string `json:"province,omitempty"`
 	AreaType *string `json:"areatype,omitempty"`
@@ -18,6 +25,7 @@ type Location struct {
 }
 
 type ForecastData struct {
+	Tc        *float64 `json:"tc"`
 	TcMin     *float64 `json:"tc_min"`
 	TcMax     *float64 `json:"tc_max"`
 	Rh        *float64 `json:"rh"`
@@ -49,6 +57,15 @@ type WeatherForecastDailyResponse struct {
 	WeatherForecasts []WeatherForecastDaily `json:"WeatherForecasts"`
 }
 
+type WeatherForecastHourly struct {
+	Location  Location   `json:"location,omitempty"`
+	Forecasts []Forecast `json:"forecasts"`
+}
+
+type WeatherForecastHourlyResponse struct {
+	WeatherForecasts []WeatherForecastHourly `json:"WeatherForecasts"`
+}
+
 type GetWeatherDailyQuery struct {
 	//at
 	Lat float32 `schema:"lat,omitempty"`
@@ -68,6 +85,27 @@ type GetWeatherDailyQuery struct {
 	Fields   string `schema:"fields"`
 }
 
+type GetWeatherHourlyQuery struct {
+	//at
+	Lat float32 `schema:"lat,omitempty"`
+	Lon float32 `schema:"lon,omitempty"`
+
+	// place
+	Province string `schema:"province,omitempty"`
+	Amphoe   string `schema:"amphoe,omitempty"`
+	Tambon   string `schema:"tambon,omitempty"`
+	Subarea  bool   `schema:"subarea,omitempty"` // 0 or 1 default 0
+
+	Date     string `schema:"date"` // YYYY-MM-DD in TMD local time
+	Hour     int    `schema:"hour"` // 0-23 in TMD local time
+	Duration int    `schema:"duration"`
+	Fields   string `schema:"fields"`
+}
+
+func (q GetWeatherDailyQuery) isPlace() bool {
+	return q.Province != "" || q.Amphoe != "" || q.Tambon != ""
+}
+
 func buildGetWeatherDailyCordinatesQuery(
 	lat float32,
 	lon float32,
@@ -104,6 +142,20 @@ func buildGetWeatherDailyPlaceQuery(
 	}
 }
 
+func buildGetWeatherDailyRegionQuery(
+	region string,
+	date string,
+	duration int,
+	fields []string,
+) GetWeatherDailyQuery {
+	return GetWeatherDailyQuery{
+		Region:   region,
+		Date:     date,
+		Duration: duration,
+		Fields:   strings.Join(fields, ","),
+	}
+}
+
 func buildGetWeatherDailyByCoordinatesQueryParams(queries GetWeatherDailyQuery) map[string]string {
 	return map[string]string{
 		"lat":      fmt.Sprintf("%f", queries.Lat),
@@ -144,3 +196,120 @@ func buildGetWeatherDailyByPlaceQueryParams(queries GetWeatherDailyQuery) map[st
 
 	return result
 }
+
+func buildGetWeatherDailyByRegionQueryParams(queries GetWeatherDailyQuery) map[string]string {
+	result := map[string]string{
+		"region": queries.Region,
+	}
+	if queries.Date != "" {
+		result["date"] = queries.Date
+	}
+	if queries.Duration != 0 {
+		result["duration"] = fmt.Sprintf("%d", queries.Duration)
+	}
+	if queries.Fields != "" {
+		result["fields"] = queries.Fields
+	}
+
+	return result
+}
+
+func buildGetWeatherHourlyCoordinatesQuery(
+	lat float32,
+	lon float32,
+	startTime time.Time,
+	duration int,
+	fields []string,
+) GetWeatherHourlyQuery {
+	localStartTime := startTime.In(tmdLocation)
+
+	return GetWeatherHourlyQuery{
+		Lat:      lat,
+		Lon:      lon,
+		Date:     localStartTime.Format(time.DateOnly),
+		Hour:     localStartTime.Hour(),
+		Duration: duration,
+		Fields:   strings.Join(fields, ","),
+	}
+}
+
+func buildGetWeatherHourlyPlaceQuery(
+	province string,
+	amphoe string,
+	tambon string,
+	subarea bool,
+	startTime time.Time,
+	duration int,
+	fields []string,
+) GetWeatherHourlyQuery {
+	localStartTime := startTime.In(tmdLocation)
+
+	return GetWeatherHourlyQuery{
+		Province: province,
+		Amphoe:   amphoe,
+		Tambon:   tambon,
+		Subarea:  subarea,
+		Date:     localStartTime.Format(time.DateOnly),
+		Hour:     localStartTime.Hour(),
+		Duration: duration,
+		Fields:   strings.Join(fields, ","),
+	}
+}
+
+func buildGetWeatherHourlyByCoordinatesQueryParams(queries GetWeatherHourlyQuery) map[string]string {
+	result := map[string]string{
+		"lat":  fmt.Sprintf("%f", queries.Lat),
+		"lon":  fmt.Sprintf("%f", queries.Lon),
+		"date": queries.Date,
+		"hour": fmt.Sprintf("%d", queries.Hour),
+	}
+	if queries.Duration != 0 {
+		result["duration"] = fmt.Sprintf("%d", queries.Duration)
+	}
+	if queries.Fields != "" {
+		result["fields"] = queries.Fields
+	}
+
+	return result
+}
+
+func buildGetWeatherHourlyByPlaceQueryParams(queries GetWeatherHourlyQuery) map[string]string {
+	result := map[string]string{
+		"date": queries.Date,
+		"hour": fmt.Sprintf("%d", queries.Hour),
+	}
+	if queries.Province != "" {
+		result["province"] = queries.Province
+	}
+	if queries.Amphoe != "" {
+		result["amphoe"] = queries.Amphoe
+	}
+	if queries.Tambon != "" {
+		result["tambon"] = queries.Tambon
+	}
+	if queries.Subarea {
+		result["subarea"] = fmt.Sprintf("%t", queries.Subarea)
+	}
+	if queries.Duration != 0 {
+		result["duration"] = fmt.Sprintf("%d", queries.Duration)
+	}
+	if queries.Fields != "" {
+		result["fields"] = queries.Fields
+	}
+
+	return result
+}
+
+// splitFields accepts both fields=a,b and fields=a&fields=b.
+func splitFields(fields []string) []string {
+	result := make([]string, 0, len(fields))
+	for _, field := range fields {
+		for _, name := range strings.Split(field, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				result = append(result, name)
+			}
+		}
+	}
+
+	return result
+}
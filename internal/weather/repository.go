@@ -1,22 +1,30 @@
 package weather
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/imroc/req/v3"
 	"github.com/olajoe/forecast_weather_api/internal/config"
-	"github.com/olajoe/forecast_weather_api/internal/utils/https"
+	"github.com/olajoe/forecast_weather_api/internal/metrics"
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
 )
 
 type WeatherRepository interface {
-	GetWeatherDailyByCoordinates(queryParams map[string]string) (*WeatherForecastDailyResponse, error)
-	GetWeatherDailyByPlace(queryParams map[string]string) (*WeatherForecastDailyResponse, error)
+	GetWeatherDailyByCoordinates(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error)
+	GetWeatherDailyByPlace(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error)
+	GetWeatherDailyByRegion(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error)
+	GetWeatherHourlyByCoordinates(ctx context.Context, queryParams map[string]string) (*WeatherForecastHourlyResponse, error)
+	GetWeatherHourlyByPlace(ctx context.Context, queryParams map[string]string) (*WeatherForecastHourlyResponse, error)
 }
 
 type weatherRepository struct {
 	client      *req.Client
 	baseUrl     string
 	accessToken string
+	retryPolicy retryPolicy
 }
 
 func NewWeatherRepository(
@@ -27,51 +35,92 @@ func NewWeatherRepository(
 		client:      client,
 		baseUrl:     cfg.Tmd.Url,
 		accessToken: cfg.Tmd.AccessToken,
+		retryPolicy: newRetryPolicy(cfg),
 	}
 }
 
-func (r *weatherRepository) GetWeatherDailyByCoordinates(queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
+func (r *weatherRepository) GetWeatherDailyByCoordinates(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
 	var resultBody WeatherForecastDailyResponse
-	var errResp https.ErrorResponse
+	if err := r.get(ctx, "/forecast/location/daily/at", queryParams, &resultBody); err != nil {
+		return nil, err
+	}
 
-	resp, err := r.client.R().
-		SetHeader("Accept", "application/json").
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", r.accessToken)).
-		SetQueryParams(queryParams).
-		SetSuccessResult(&resultBody).
-		SetErrorResult(&errResp).
-		Get(fmt.Sprintf("%s/forecast/location/daily/at", r.baseUrl))
+	return &resultBody, nil
+}
 
-	if err != nil {
+func (r *weatherRepository) GetWeatherDailyByPlace(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
+	var resultBody WeatherForecastDailyResponse
+	if err := r.get(ctx, "/forecast/location/daily/place", queryParams, &resultBody); err != nil {
 		return nil, err
 	}
 
-	if resp.IsErrorState() {
-		return nil, errResp
+	return &resultBody, nil
+}
+
+func (r *weatherRepository) GetWeatherDailyByRegion(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
+	var resultBody WeatherForecastDailyResponse
+	if err := r.get(ctx, "/forecast/location/daily/region", queryParams, &resultBody); err != nil {
+		return nil, err
 	}
 
 	return &resultBody, nil
 }
 
-func (r *weatherRepository) GetWeatherDailyByPlace(queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
-	var resultBody WeatherForecastDailyResponse
-	var errResp https.ErrorResponse
+func (r *weatherRepository) GetWeatherHourlyByCoordinates(ctx context.Context, queryParams map[string]string) (*WeatherForecastHourlyResponse, error) {
+	var resultBody WeatherForecastHourlyResponse
+	if err := r.get(ctx, "/forecast/location/hourly/at", queryParams, &resultBody); err != nil {
+		return nil, err
+	}
 
-	resp, err := r.client.R().
+	return &resultBody, nil
+}
+
+func (r *weatherRepository) GetWeatherHourlyByPlace(ctx context.Context, queryParams map[string]string) (*WeatherForecastHourlyResponse, error) {
+	var resultBody WeatherForecastHourlyResponse
+	if err := r.get(ctx, "/forecast/location/hourly/place", queryParams, &resultBody); err != nil {
+		return nil, err
+	}
+
+	return &resultBody, nil
+}
+
+func (r *weatherRepository) get(ctx context.Context, path string, queryParams map[string]string, resultBody interface{}) (err error) {
+	startTime := time.Now()
+	defer func() {
+		metrics.ObserveTMDRequest(path, upstreamOutcome(err), time.Since(startTime))
+	}()
+
+	if r.retryPolicy.budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.retryPolicy.budget)
+		defer cancel()
+	}
+
+	request := r.client.R()
+	if correlationID := logging.CorrelationIDFromContext(ctx); correlationID != "" {
+		request.SetHeader(logging.HeaderCorrelationID, correlationID)
+	}
+
+	resp, err := r.retryPolicy.apply(request).
+		SetContext(ctx).
 		SetHeader("Accept", "application/json").
 		SetHeader("Authorization", fmt.Sprintf("Bearer %s", r.accessToken)).
 		SetQueryParams(queryParams).
-		SetSuccessResult(&resultBody).
-		SetErrorResult(&errResp).
-		Get(fmt.Sprintf("%s/forecast/location/daily/place", r.baseUrl))
+		SetSuccessResult(resultBody).
+		Get(fmt.Sprintf("%s%s", r.baseUrl, path))
+	metrics.AddTMDRetries(path, resp.Request.RetryAttempt)
 
 	if err != nil {
-		return nil, err
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRateLimited) {
+			return err
+		}
+
+		return fmt.Errorf("%w: %w", ErrUpstreamUnavailable, err)
 	}
 
 	if resp.IsErrorState() {
-		return nil, errResp
+		return newUpstreamError(resp)
 	}
 
-	return &resultBody, nil
+	return nil
 }
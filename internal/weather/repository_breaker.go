@@ -0,0 +1,83 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/olajoe/forecast_weather_api/internal/breaker"
+)
+
+// circuitBreakerWeatherRepository fails fast with breaker.ErrOpen while TMD is
+// considered down instead of waiting for every request to time out.
+type circuitBreakerWeatherRepository struct {
+	weatherRepository WeatherRepository
+	breaker           *breaker.Breaker
+}
+
+func NewCircuitBreakerWeatherRepository(
+	weatherRepository WeatherRepository,
+	breaker *breaker.Breaker,
+) WeatherRepository {
+	return &circuitBreakerWeatherRepository{
+		weatherRepository: weatherRepository,
+		breaker:           breaker,
+	}
+}
+
+func (r *circuitBreakerWeatherRepository) GetWeatherDailyByCoordinates(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
+	return executeWithBreaker(ctx, r.breaker, queryParams, r.weatherRepository.GetWeatherDailyByCoordinates)
+}
+
+func (r *circuitBreakerWeatherRepository) GetWeatherDailyByPlace(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
+	return executeWithBreaker(ctx, r.breaker, queryParams, r.weatherRepository.GetWeatherDailyByPlace)
+}
+
+func (r *circuitBreakerWeatherRepository) GetWeatherDailyByRegion(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
+	return executeWithBreaker(ctx, r.breaker, queryParams, r.weatherRepository.GetWeatherDailyByRegion)
+}
+
+func (r *circuitBreakerWeatherRepository) GetWeatherHourlyByCoordinates(ctx context.Context, queryParams map[string]string) (*WeatherForecastHourlyResponse, error) {
+	return executeWithBreaker(ctx, r.breaker, queryParams, r.weatherRepository.GetWeatherHourlyByCoordinates)
+}
+
+func (r *circuitBreakerWeatherRepository) GetWeatherHourlyByPlace(ctx context.Context, queryParams map[string]string) (*WeatherForecastHourlyResponse, error) {
+	return executeWithBreaker(ctx, r.breaker, queryParams, r.weatherRepository.GetWeatherHourlyByPlace)
+}
+
+func executeWithBreaker[T any](
+	ctx context.Context,
+	b *breaker.Breaker,
+	queryParams map[string]string,
+	fetch func(context.Context, map[string]string) (*T, error),
+) (*T, error) {
+	var result *T
+	err := b.Execute(func() error {
+		var err error
+		result, err = fetch(ctx, queryParams)
+		return err
+	})
+	if errors.Is(err, breaker.ErrOpen) {
+		return nil, fmt.Errorf("%w: %w", ErrUpstreamUnavailable, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// IsInconclusive reports whether err says nothing about the health of TMD
+// because the call was cancelled or never sent for lack of a rate limiter token.
+func IsInconclusive(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, ErrRateLimited)
+}
+
+// IsUpstreamFailure reports whether err means TMD is unhealthy. Client errors
+// such as an unknown province, an expired token or a cancelled request do not
+// count against the breaker.
+func IsUpstreamFailure(err error) bool {
+	return errors.Is(err, ErrUpstreamUnavailable) ||
+		errors.Is(err, ErrUpstreamRateLimited) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
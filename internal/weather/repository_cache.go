@@ -0,0 +1,215 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/olajoe/forecast_weather_api/internal/breaker"
+	"github.com/olajoe/forecast_weather_api/internal/cache"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
+	"golang.org/x/sync/singleflight"
+)
+
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	StaleHits int64 `json:"staleHits"`
+}
+
+// CachedWeatherRepository is a WeatherRepository that serves repeated queries
+// from a cache instead of calling TMD.
+type CachedWeatherRepository interface {
+	WeatherRepository
+	Stats() CacheStats
+}
+
+type cachedWeatherRepository struct {
+	weatherRepository WeatherRepository
+	cache             cache.Cache
+	ttl               time.Duration
+	staleTTL          time.Duration
+	fetchTimeout      time.Duration
+	now               func() time.Time
+	group             singleflight.Group
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	staleHits atomic.Int64
+}
+
+// cacheEntry keeps entries past ttl so they can be served stale while the
+// circuit breaker is open.
+type cacheEntry struct {
+	StoredAt time.Time       `json:"storedAt"`
+	Data     json.RawMessage `json:"data"`
+}
+
+func NewCachedWeatherRepository(
+	weatherRepository WeatherRepository,
+	cache cache.Cache,
+	cfg *config.Configuration,
+) CachedWeatherRepository {
+	return &cachedWeatherRepository{
+		weatherRepository: weatherRepository,
+		cache:             cache,
+		ttl:               cfg.Cache.TTL,
+		staleTTL:          max(cfg.Cache.StaleTTL, cfg.Cache.TTL),
+		fetchTimeout:      cfg.Timeout.Default,
+		now:               time.Now,
+	}
+}
+
+func (r *cachedWeatherRepository) Stats() CacheStats {
+	return CacheStats{
+		Hits:      r.hits.Load(),
+		Misses:    r.misses.Load(),
+		StaleHits: r.staleHits.Load(),
+	}
+}
+
+func (r *cachedWeatherRepository) GetWeatherDailyByCoordinates(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
+	return getCached(ctx, r, "daily/at", queryParams, r.weatherRepository.GetWeatherDailyByCoordinates)
+}
+
+func (r *cachedWeatherRepository) GetWeatherDailyByPlace(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
+	return getCached(ctx, r, "daily/place", queryParams, r.weatherRepository.GetWeatherDailyByPlace)
+}
+
+func (r *cachedWeatherRepository) GetWeatherDailyByRegion(ctx context.Context, queryParams map[string]string) (*WeatherForecastDailyResponse, error) {
+	return getCached(ctx, r, "daily/region", queryParams, r.weatherRepository.GetWeatherDailyByRegion)
+}
+
+func (r *cachedWeatherRepository) GetWeatherHourlyByCoordinates(ctx context.Context, queryParams map[string]string) (*WeatherForecastHourlyResponse, error) {
+	return getCached(ctx, r, "hourly/at", queryParams, r.weatherRepository.GetWeatherHourlyByCoordinates)
+}
+
+func (r *cachedWeatherRepository) GetWeatherHourlyByPlace(ctx context.Context, queryParams map[string]string) (*WeatherForecastHourlyResponse, error) {
+	return getCached(ctx, r, "hourly/place", queryParams, r.weatherRepository.GetWeatherHourlyByPlace)
+}
+
+// getCached collapses concurrent misses of the same key into a single fetch.
+// Errors are never cached and a failing cache backend is treated as a miss.
+// An expired entry is still returned when the circuit breaker is open or the
+// TMD call is rate limited.
+func getCached[T any](
+	ctx context.Context,
+	r *cachedWeatherRepository,
+	endpoint string,
+	queryParams map[string]string,
+	fetch func(context.Context, map[string]string) (*T, error),
+) (*T, error) {
+	key := buildCacheKey(endpoint, queryParams)
+
+	var stale *T
+	if entry, ok := r.getEntry(ctx, key); ok {
+		var result T
+		if err := json.Unmarshal(entry.Data, &result); err == nil {
+			if r.now().Sub(entry.StoredAt) < r.ttl {
+				r.hits.Add(1)
+				return &result, nil
+			}
+
+			stale = &result
+		}
+	}
+
+	r.misses.Add(1)
+
+	// The shared fetch keeps the values of the first caller's context, such as
+	// its logger and trace, but not its cancellation so that a caller that
+	// goes away does not fail the others. Every caller still stops waiting as
+	// soon as its own context is done.
+	resultChan := r.group.DoChan(key, func() (interface{}, error) {
+		fetchCtx := context.WithoutCancel(ctx)
+		if r.fetchTimeout > 0 {
+			var cancel context.CancelFunc
+			fetchCtx, cancel = context.WithTimeout(fetchCtx, r.fetchTimeout)
+			defer cancel()
+		}
+
+		result, err := fetch(fetchCtx, queryParams)
+		if err != nil {
+			return nil, err
+		}
+
+		r.setEntry(fetchCtx, key, result)
+
+		return result, nil
+	})
+
+	var value interface{}
+	var err error
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resultChan:
+		value, err = res.Val, res.Err
+	}
+
+	if (errors.Is(err, breaker.ErrOpen) || errors.Is(err, ErrRateLimited)) && stale != nil {
+		r.staleHits.Add(1)
+		return stale, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*T), nil
+}
+
+func (r *cachedWeatherRepository) getEntry(ctx context.Context, key string) (cacheEntry, bool) {
+	var entry cacheEntry
+
+	cached, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		logging.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("cannot get cached weather forecast")
+		return entry, false
+	}
+	if !ok {
+		return entry, false
+	}
+
+	if err := json.Unmarshal(cached, &entry); err != nil {
+		return entry, false
+	}
+
+	return entry, true
+}
+
+func (r *cachedWeatherRepository) setEntry(ctx context.Context, key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	encoded, err := json.Marshal(cacheEntry{StoredAt: r.now(), Data: data})
+	if err != nil {
+		return
+	}
+
+	if err := r.cache.Set(ctx, key, encoded, r.staleTTL); err != nil {
+		logging.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("cannot cache weather forecast")
+	}
+}
+
+// buildCacheKey normalises query params so that the same query always maps to
+// the same key regardless of map order or surrounding whitespace.
+func buildCacheKey(endpoint string, queryParams map[string]string) string {
+	values := url.Values{}
+	for key, value := range queryParams {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		values.Set(strings.ToLower(key), value)
+	}
+
+	return endpoint + "?" + values.Encode()
+}
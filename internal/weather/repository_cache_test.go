@@ -0,0 +1,259 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olajoe/forecast_weather_api/internal/breaker"
+	"github.com/olajoe/forecast_weather_api/internal/cache"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+)
+
+// fakeWeatherRepository counts calls and answers every query with daily,
+// after release is closed when it is set.
+type fakeWeatherRepository struct {
+	calls   atomic.Int64
+	release chan struct{}
+	daily   func(ctx context.Context) (*WeatherForecastDailyResponse, error)
+}
+
+func (f *fakeWeatherRepository) getDaily(ctx context.Context) (*WeatherForecastDailyResponse, error) {
+	f.calls.Add(1)
+	if f.release != nil {
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return f.daily(ctx)
+}
+
+func (f *fakeWeatherRepository) GetWeatherDailyByCoordinates(ctx context.Context, _ map[string]string) (*WeatherForecastDailyResponse, error) {
+	return f.getDaily(ctx)
+}
+
+func (f *fakeWeatherRepository) GetWeatherDailyByPlace(ctx context.Context, _ map[string]string) (*WeatherForecastDailyResponse, error) {
+	return f.getDaily(ctx)
+}
+
+func (f *fakeWeatherRepository) GetWeatherDailyByRegion(ctx context.Context, _ map[string]string) (*WeatherForecastDailyResponse, error) {
+	return f.getDaily(ctx)
+}
+
+func (f *fakeWeatherRepository) GetWeatherHourlyByCoordinates(context.Context, map[string]string) (*WeatherForecastHourlyResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeWeatherRepository) GetWeatherHourlyByPlace(context.Context, map[string]string) (*WeatherForecastHourlyResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func dailyResponse(province string) *WeatherForecastDailyResponse {
+	return &WeatherForecastDailyResponse{WeatherForecasts: []WeatherForecastDaily{{Location: Location{Province: &province}}}}
+}
+
+func newTestCachedRepository(repo WeatherRepository, now func() time.Time) *cachedWeatherRepository {
+	cfg := &config.Configuration{
+		Cache:   config.CacheConfig{TTL: time.Minute, StaleTTL: time.Hour},
+		Timeout: config.TimeoutConfig{Default: 5 * time.Second},
+	}
+	r := NewCachedWeatherRepository(repo, cache.NewMemoryCache(100), cfg).(*cachedWeatherRepository)
+	r.now = now
+
+	return r
+}
+
+func TestCachedRepositorySharedFetchOutlivesFirstCaller(t *testing.T) {
+	fake := &fakeWeatherRepository{
+		release: make(chan struct{}),
+		daily: func(context.Context) (*WeatherForecastDailyResponse, error) {
+			return dailyResponse("Bangkok"), nil
+		},
+	}
+	r := newTestCachedRepository(fake, time.Now)
+	queryParams := map[string]string{"province": "Bangkok"}
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := r.GetWeatherDailyByPlace(firstCtx, queryParams)
+		firstErr <- err
+	}()
+	waitForCalls(t, fake, 1)
+
+	var secondErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, secondErr = r.GetWeatherDailyByPlace(context.Background(), queryParams)
+	}()
+
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller error = %v, want context.Canceled", err)
+	}
+
+	close(fake.release)
+	wg.Wait()
+	if secondErr != nil {
+		t.Fatalf("second caller error = %v, want nil", secondErr)
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("upstream calls = %d, want 1", calls)
+	}
+
+	if _, err := r.GetWeatherDailyByPlace(context.Background(), queryParams); err != nil {
+		t.Fatalf("cached call error = %v", err)
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("upstream calls after cache hit = %d, want 1", calls)
+	}
+}
+
+func waitForCalls(t *testing.T, fake *fakeWeatherRepository, calls int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for fake.calls.Load() < calls {
+		if time.Now().After(deadline) {
+			t.Fatalf("upstream calls = %d, want %d", fake.calls.Load(), calls)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCachedRepositoryHitsAndTTL(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	fake := &fakeWeatherRepository{daily: func(context.Context) (*WeatherForecastDailyResponse, error) {
+		return dailyResponse("Bangkok"), nil
+	}}
+	r := newTestCachedRepository(fake, func() time.Time { return now })
+	ctx := context.Background()
+
+	get := func(queryParams map[string]string) {
+		t.Helper()
+
+		result, err := r.GetWeatherDailyByPlace(ctx, queryParams)
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if province := result.WeatherForecasts[0].Location.Province; province == nil || *province != "Bangkok" {
+			t.Fatalf("province = %v, want Bangkok", province)
+		}
+	}
+
+	get(map[string]string{"province": "Bangkok", "fields": "tc"})
+	// same query in another order and case
+	get(map[string]string{"Fields": " tc ", "province": "Bangkok"})
+
+	now = now.Add(59 * time.Second)
+	get(map[string]string{"province": "Bangkok", "fields": "tc"})
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("upstream calls before expiry = %d, want 1", calls)
+	}
+
+	now = now.Add(time.Second)
+	get(map[string]string{"province": "Bangkok", "fields": "tc"})
+	if calls := fake.calls.Load(); calls != 2 {
+		t.Fatalf("upstream calls after expiry = %d, want 2", calls)
+	}
+
+	if stats := r.Stats(); stats != (CacheStats{Hits: 2, Misses: 2}) {
+		t.Fatalf("stats = %+v, want 2 hits and 2 misses", stats)
+	}
+}
+
+func TestCachedRepositoryCollapsesConcurrentMisses(t *testing.T) {
+	fake := &fakeWeatherRepository{
+		release: make(chan struct{}),
+		daily: func(context.Context) (*WeatherForecastDailyResponse, error) {
+			return dailyResponse("Bangkok"), nil
+		},
+	}
+	r := newTestCachedRepository(fake, time.Now)
+	queryParams := map[string]string{"province": "Bangkok"}
+
+	const callers = 20
+	errs := make(chan error, callers)
+	for range callers {
+		go func() {
+			_, err := r.GetWeatherDailyByPlace(context.Background(), queryParams)
+			errs <- err
+		}()
+	}
+
+	waitForCalls(t, fake, 1)
+	// give every caller the time to join the in-flight fetch
+	time.Sleep(50 * time.Millisecond)
+	close(fake.release)
+
+	for range callers {
+		if err := <-errs; err != nil {
+			t.Fatalf("error = %v", err)
+		}
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("upstream calls = %d, want 1", calls)
+	}
+	if stats := r.Stats(); stats.Misses != callers {
+		t.Fatalf("misses = %d, want %d", stats.Misses, callers)
+	}
+}
+
+func TestCachedRepositoryErrors(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	upstreamErr := error(nil)
+	fake := &fakeWeatherRepository{daily: func(context.Context) (*WeatherForecastDailyResponse, error) {
+		if upstreamErr != nil {
+			return nil, upstreamErr
+		}
+
+		return dailyResponse("Bangkok"), nil
+	}}
+	r := newTestCachedRepository(fake, func() time.Time { return now })
+	ctx := context.Background()
+	queryParams := map[string]string{"province": "Bangkok"}
+
+	if _, err := r.GetWeatherDailyByPlace(ctx, queryParams); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+
+	tests := []struct {
+		name      string
+		err       error
+		wantStale bool
+	}{
+		{"breaker open serves stale", breaker.ErrOpen, true},
+		{"rate limited serves stale", ErrRateLimited, true},
+		{"other errors are returned", ErrUpstreamUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstreamErr = tt.err
+
+			result, err := r.GetWeatherDailyByPlace(ctx, queryParams)
+			if tt.wantStale {
+				if err != nil || result == nil {
+					t.Fatalf("result = %v, error = %v, want the stale entry", result, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	if stats := r.Stats(); stats.StaleHits != 2 {
+		t.Fatalf("stale hits = %d, want 2", stats.StaleHits)
+	}
+}
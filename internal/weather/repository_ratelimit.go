@@ -0,0 +1,29 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/imroc/req/v3"
+	"github.com/olajoe/forecast_weather_api/internal/ratelimit"
+)
+
+// NewRateLimitedClient keeps calls to TMD under the quota of our access token
+// so that a traffic spike does not get the token throttled. Every attempt,
+// retries included, takes a token, a request that cannot get one fails with
+// ErrRateLimited and is not retried.
+func NewRateLimitedClient(client *req.Client, limiter *ratelimit.Limiter) *req.Client {
+	return client.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+		return func(r *req.Request) (*req.Response, error) {
+			if err := limiter.Wait(r.Context()); err != nil {
+				if errors.Is(err, ratelimit.ErrLimited) {
+					err = fmt.Errorf("%w: %w", ErrRateLimited, err)
+				}
+
+				return &req.Response{Request: r}, err
+			}
+
+			return rt.RoundTrip(r)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/olajoe/forecast_weather_api/internal/ratelimit"
+)
+
+func TestRateLimitedClientTakesATokenPerAttempt(t *testing.T) {
+	tests := []struct {
+		name        string
+		burst       int
+		wantCalls   int64
+		wantErr     error
+		wantAllowed int64
+	}{
+		{"enough tokens for every retry", 3, 3, nil, 3},
+		{"retry without a token is not sent", 1, 1, ErrRateLimited, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := failingServer(t, 2, respondWithStatus(http.StatusServiceUnavailable))
+			limiter := ratelimit.New(0.001, tt.burst, 0)
+			client := NewRateLimitedClient(req.C(), limiter)
+			cfg := &config.Configuration{Tmd: config.TmdConfig{Url: server.URL, Timeout: 5 * time.Second, Retry: fastRetry}}
+			repo := NewWeatherRepository(client, cfg)
+
+			_, err := repo.GetWeatherDailyByCoordinates(context.Background(), nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrUpstreamUnavailable) {
+				t.Fatalf("error = %v, want a rate limited call not to count as TMD being unavailable", err)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("TMD calls = %d, want %d", got, tt.wantCalls)
+			}
+			if stats := limiter.Stats(); stats.Allowed != tt.wantAllowed {
+				t.Fatalf("allowed = %d, want %d", stats.Allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
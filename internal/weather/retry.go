@@ -0,0 +1,130 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/olajoe/forecast_weather_api/pkg/logging"
+)
+
+// retryPolicy retries idempotent TMD requests with exponential backoff and
+// full jitter until maxRetries or the time budget is used up.
+type retryPolicy struct {
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	budget     time.Duration
+}
+
+func newRetryPolicy(cfg *config.Configuration) retryPolicy {
+	return retryPolicy{
+		maxRetries: cfg.Tmd.Retry.Count,
+		minBackoff: cfg.Tmd.Retry.MinBackoff,
+		maxBackoff: cfg.Tmd.Retry.MaxBackoff,
+		budget:     cfg.Tmd.Retry.Budget,
+	}
+}
+
+// apply enables retries on a single GET request. The returned request must
+// not be shared since the retry state belongs to it.
+func (p retryPolicy) apply(request *req.Request) *req.Request {
+	if p.maxRetries <= 0 {
+		return request
+	}
+
+	startTime := time.Now()
+	var delay time.Duration
+
+	return request.
+		SetRetryCount(p.maxRetries).
+		SetRetryCondition(func(resp *req.Response, err error) bool {
+			if !isRetryable(resp, err) {
+				return false
+			}
+
+			delay = p.backoff(resp.Request.RetryAttempt + 1)
+			if retryAfter, ok := parseRetryAfter(resp); ok {
+				delay = retryAfter
+			}
+
+			return p.budget <= 0 || time.Since(startTime)+delay < p.budget
+		}).
+		SetRetryInterval(func(_ *req.Response, _ int) time.Duration {
+			return delay
+		}).
+		AddRetryHook(func(resp *req.Response, err error) {
+			logger := logging.Ctx(resp.Request.Context())
+			e := logger.Warn().
+				Str("url", resp.Request.RawURL).
+				Int("attempt", resp.Request.RetryAttempt).
+				Str("delay", delay.String())
+			if resp.Response != nil {
+				e = e.Int("statusCode", resp.StatusCode)
+			}
+			e.Err(err).Msg("retry TMD request")
+		})
+}
+
+// backoff returns a random delay up to minBackoff * 2^(attempt-1), capped at maxBackoff.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	// Double up to maxBackoff instead of shifting so that large attempt counts
+	// cannot overflow into a short or negative delay.
+	backoff := p.minBackoff
+	for i := 1; i < attempt && backoff > 0 && backoff < p.maxBackoff; i++ {
+		if backoff > p.maxBackoff/2 {
+			backoff = p.maxBackoff
+			break
+		}
+		backoff *= 2
+	}
+	if backoff > p.maxBackoff || backoff <= 0 {
+		backoff = p.maxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	return rand.N(backoff + 1)
+}
+
+// isRetryable retries connection errors, 429 and 5xx responses. Attempts that
+// did not get a rate limiter token are not retried.
+func isRetryable(resp *req.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrRateLimited)
+	}
+
+	if resp.Response == nil {
+		return false
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter reads the Retry-After header in seconds or as an HTTP date.
+func parseRetryAfter(resp *req.Response) (time.Duration, bool) {
+	if resp.Response == nil {
+		return 0, false
+	}
+
+	retryAfter := resp.Header.Get("Retry-After")
+	if retryAfter == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,203 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+)
+
+// failingServer fails the first failures requests with fail and answers the
+// others with an empty forecast.
+func failingServer(t *testing.T, failures int64, fail http.HandlerFunc) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			fail(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"WeatherForecasts":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func respondWithStatus(status int, header ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(status)
+	}
+}
+
+func closeConnection(w http.ResponseWriter, _ *http.Request) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func newTestRetryRepository(url string, retry config.RetryConfig) WeatherRepository {
+	cfg := &config.Configuration{Tmd: config.TmdConfig{Url: url, Timeout: 5 * time.Second, Retry: retry}}
+
+	return NewWeatherRepository(req.C(), cfg)
+}
+
+var fastRetry = config.RetryConfig{Count: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int64
+		fail     http.HandlerFunc
+	}{
+		{"server error", 3, respondWithStatus(http.StatusServiceUnavailable)},
+		{"too many requests", 2, respondWithStatus(http.StatusTooManyRequests)},
+		{"closed connection", 2, closeConnection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := failingServer(t, tt.failures, tt.fail)
+			repo := newTestRetryRepository(server.URL, fastRetry)
+
+			if _, err := repo.GetWeatherDailyByCoordinates(context.Background(), nil); err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if got, want := calls.Load(), tt.failures+1; got != want {
+				t.Fatalf("calls = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestRetryGivesUpAfterCount(t *testing.T) {
+	server, calls := failingServer(t, 10, respondWithStatus(http.StatusBadGateway))
+	repo := newTestRetryRepository(server.URL, fastRetry)
+
+	_, err := repo.GetWeatherDailyByCoordinates(context.Background(), nil)
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("error = %v, want ErrUpstreamUnavailable", err)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Fatalf("calls = %d, want 4", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter func() string
+	}{
+		{"seconds", func() string { return "1" }},
+		{"http date", func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := failingServer(t, 1, func(w http.ResponseWriter, r *http.Request) {
+				respondWithStatus(http.StatusTooManyRequests, "Retry-After", tt.retryAfter())(w, r)
+			})
+			repo := newTestRetryRepository(server.URL, fastRetry)
+
+			startTime := time.Now()
+			if _, err := repo.GetWeatherDailyByCoordinates(context.Background(), nil); err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if elapsed := time.Since(startTime); elapsed < 900*time.Millisecond {
+				t.Fatalf("retried after %s, want Retry-After to be honoured", elapsed)
+			}
+			if got := calls.Load(); got != 2 {
+				t.Fatalf("calls = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestRetryStopsAtBudget(t *testing.T) {
+	server, calls := failingServer(t, 10, respondWithStatus(http.StatusServiceUnavailable, "Retry-After", "1"))
+	retry := fastRetry
+	retry.Budget = 500 * time.Millisecond
+	repo := newTestRetryRepository(server.URL, retry)
+
+	startTime := time.Now()
+	_, err := repo.GetWeatherDailyByCoordinates(context.Background(), nil)
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("error = %v, want ErrUpstreamUnavailable", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("calls = %d, want 1 since the Retry-After delay exceeds the budget", got)
+	}
+	if elapsed := time.Since(startTime); elapsed >= retry.Budget {
+		t.Fatalf("took %s, want to give up before the budget", elapsed)
+	}
+}
+
+func TestNoRetryOnClientErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusBadRequest, ErrInvalidLocation},
+		{http.StatusUnauthorized, ErrUpstreamUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			server, calls := failingServer(t, 10, respondWithStatus(tt.status))
+			repo := newTestRetryRepository(server.URL, fastRetry)
+
+			_, err := repo.GetWeatherDailyByCoordinates(context.Background(), nil)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			if got := calls.Load(); got != 1 {
+				t.Fatalf("calls = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestRetryBackoffNeverWraps(t *testing.T) {
+	policies := []retryPolicy{
+		{minBackoff: 100 * time.Millisecond, maxBackoff: 10 * time.Second},
+		// minBackoff << 24 wraps to 16ms
+		{minBackoff: 1<<40 + 1, maxBackoff: 1 << 62},
+	}
+
+	for _, policy := range policies {
+		for attempt := 1; attempt <= 130; attempt++ {
+			// the cap is minBackoff * 2^(attempt-1), at most maxBackoff
+			limit := policy.maxBackoff
+			if float64(policy.minBackoff)*math.Pow(2, float64(attempt-1)) < float64(policy.maxBackoff) {
+				limit = policy.minBackoff << (attempt - 1)
+			}
+
+			var longest time.Duration
+			for range 100 {
+				backoff := policy.backoff(attempt)
+				if backoff < 0 || backoff > limit {
+					t.Fatalf("backoff(%d) = %s, want within [0, %s]", attempt, backoff, limit)
+				}
+				longest = max(longest, backoff)
+			}
+			if longest < limit/2 {
+				t.Fatalf("backoff(%d) stayed under %s, want delays up to %s", attempt, longest, limit)
+			}
+		}
+	}
+}
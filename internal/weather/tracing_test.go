@@ -0,0 +1,111 @@
+package weather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+	"github.com/imroc/req/v3"
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	"github.com/olajoe/forecast_weather_api/internal/tracing"
+	"github.com/olajoe/forecast_weather_api/internal/validator"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	testSpanExporter      = tracetest.NewInMemoryExporter()
+	testTracerProviderErr error
+	installTracerOnce     sync.Once
+)
+
+// installTestTracerProvider sets the global tracer provider once per test
+// binary since the package tracer keeps delegating to the first one set.
+func installTestTracerProvider(t *testing.T, cfg *config.Configuration) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	installTracerOnce.Do(func() {
+		var provider *sdktrace.TracerProvider
+		provider, testTracerProviderErr = tracing.NewTracerProvider(sdktrace.NewSimpleSpanProcessor(testSpanExporter), cfg)
+		if testTracerProviderErr == nil {
+			otel.SetTracerProvider(provider)
+			otel.SetTextMapPropagator(propagation.TraceContext{})
+		}
+	})
+	if testTracerProviderErr != nil {
+		t.Fatal(testTracerProviderErr)
+	}
+	testSpanExporter.Reset()
+
+	return testSpanExporter
+}
+
+func TestTraceFromServerToTMD(t *testing.T) {
+	cfg := &config.Configuration{
+		Tmd:     config.TmdConfig{Timeout: 5 * time.Second},
+		Tracing: config.TracingConfig{ServiceName: "test", SampleRatio: 1},
+	}
+
+	exporter := installTestTracerProvider(t, cfg)
+
+	traceparents := make(chan string, 1)
+	tmd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"WeatherForecasts":[{"location":{"lat":13.75,"lon":100.5},"forecasts":[{"time":"2026-10-18T00:00:00+07:00","data":{"tc_max":33}}]}]}`))
+	}))
+	t.Cleanup(tmd.Close)
+	cfg.Tmd.Url = tmd.URL
+
+	validate := validator.NewValidator()
+	translator, err := validator.NewTranslator(validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewWeatherRepository(tracing.InstrumentClient(req.C()), cfg)
+	handler := NewWeatherHandler(validate, translator, schema.NewDecoder(), NewWeatherUsecase(repo, cfg), cfg)
+
+	router := mux.NewRouter()
+	router.Use(tracing.NewMiddleware())
+	router.HandleFunc("/v1/weathers/daily/at", handler.GetWeatherForecastDailyByCoordinates)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/weathers/daily/at?lat=13.75&lon=100.5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3: %v", len(spans), spans)
+	}
+	// spans are exported as they end, innermost first
+	client, usecase, server := spans[0], spans[1], spans[2]
+
+	assertSpan := func(span tracetest.SpanStub, name string, kind trace.SpanKind, parent trace.SpanContext) {
+		t.Helper()
+
+		if span.Name != name || span.SpanKind != kind {
+			t.Errorf("span = %q %s, want %q %s", span.Name, span.SpanKind, name, kind)
+		}
+		if span.Parent.SpanID() != parent.SpanID() || span.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("span %q parent = %s, want %s in trace %s", span.Name, span.Parent.SpanID(), parent.SpanID(), server.SpanContext.TraceID())
+		}
+	}
+	assertSpan(server, "GET /v1/weathers/daily/at", trace.SpanKindServer, trace.SpanContext{})
+	assertSpan(usecase, "WeatherUsecase.GetWeatherDailyByCoordinates", trace.SpanKindInternal, server.SpanContext)
+	assertSpan(client, http.MethodGet, trace.SpanKindClient, usecase.SpanContext)
+
+	traceparent := <-traceparents
+	want := "00-" + client.SpanContext.TraceID().String() + "-" + client.SpanContext.SpanID().String() + "-01"
+	if traceparent != want {
+		t.Fatalf("traceparent = %q, want %q", traceparent, want)
+	}
+}
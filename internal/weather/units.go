@@ -0,0 +1,103 @@
+package weather
+
+import (
+	"math"
+
+	apiv1 "github.com/olajoe/forecast_weather_api/pkg/api/v1"
+)
+
+type convertFunc func(float64) float64
+
+// unitConverter converts TMD's metric values into a unit system. A nil
+// convertFunc keeps the TMD value as is, unrounded.
+type unitConverter struct {
+	units       apiv1.Units
+	temperature convertFunc
+	rain        convertFunc
+	windSpeed   convertFunc
+	slp         convertFunc
+	psfc        convertFunc
+}
+
+var unitConverters = map[string]unitConverter{
+	apiv1.UnitsMetric: {
+		units: metricUnits,
+	},
+	apiv1.UnitsImperial: {
+		units: unitsWith(apiv1.Units{
+			Tc:    "°F",
+			TcMin: "°F",
+			TcMax: "°F",
+			Slp:   "inHg",
+			Psfc:  "inHg",
+			Rain:  "in",
+			Ws10m: "mph",
+		}),
+		temperature: func(c float64) float64 { return c*9/5 + 32 },
+		rain:        func(mm float64) float64 { return mm / 25.4 },
+		windSpeed:   func(ms float64) float64 { return ms * 2.236936 },
+		slp:         func(hPa float64) float64 { return hPa * 0.02953 },
+		psfc:        func(pa float64) float64 { return pa * 0.0002953 },
+	},
+	apiv1.UnitsSI: {
+		units: unitsWith(apiv1.Units{
+			Tc:    "K",
+			TcMin: "K",
+			TcMax: "K",
+			Slp:   "Pa",
+			Psfc:  "Pa",
+			Rain:  "m",
+			Ws10m: "m/s",
+		}),
+		temperature: func(c float64) float64 { return c + 273.15 },
+		rain:        func(mm float64) float64 { return mm / 1000 },
+		slp:         func(hPa float64) float64 { return hPa * 100 },
+	},
+}
+
+// unitsWith overrides the converted unit labels on top of metricUnits.
+func unitsWith(converted apiv1.Units) apiv1.Units {
+	units := metricUnits
+	units.Tc = converted.Tc
+	units.TcMin = converted.TcMin
+	units.TcMax = converted.TcMax
+	units.Slp = converted.Slp
+	units.Psfc = converted.Psfc
+	units.Rain = converted.Rain
+	units.Ws10m = converted.Ws10m
+
+	return units
+}
+
+// getUnitConverter falls back to metric, the unit system TMD reports in.
+func getUnitConverter(system string) unitConverter {
+	converter, ok := unitConverters[system]
+	if !ok {
+		return unitConverters[apiv1.UnitsMetric]
+	}
+
+	return converter
+}
+
+func (c unitConverter) convert(values apiv1.ForecastValues) apiv1.ForecastValues {
+	values.Tc = convertValue(values.Tc, c.temperature)
+	values.TcMin = convertValue(values.TcMin, c.temperature)
+	values.TcMax = convertValue(values.TcMax, c.temperature)
+	values.Slp = convertValue(values.Slp, c.slp)
+	values.Psfc = convertValue(values.Psfc, c.psfc)
+	values.Rain = convertValue(values.Rain, c.rain)
+	values.Ws10m = convertValue(values.Ws10m, c.windSpeed)
+
+	return values
+}
+
+func convertValue(value *float64, convert convertFunc) *float64 {
+	if value == nil || convert == nil {
+		return value
+	}
+
+	// Round to keep converted values readable, e.g. 31.2 °C is 88.16 °F.
+	result := math.Round(convert(*value)*10000) / 10000
+
+	return &result
+}
@@ -0,0 +1,52 @@
+package weather
+
+import (
+	"testing"
+
+	apiv1 "github.com/olajoe/forecast_weather_api/pkg/api/v1"
+)
+
+func TestUnitConverterConvert(t *testing.T) {
+	values := apiv1.ForecastValues{Tc: ptr(31.23456), Rain: ptr(12.7), Ws10m: ptr(3.3333333), Psfc: ptr(100812.5)}
+
+	tests := []struct {
+		system string
+		want   apiv1.ForecastValues
+	}{
+		{apiv1.UnitsMetric, apiv1.ForecastValues{Tc: ptr(31.23456), Rain: ptr(12.7), Ws10m: ptr(3.3333333), Psfc: ptr(100812.5)}},
+		{apiv1.UnitsImperial, apiv1.ForecastValues{Tc: ptr(88.2222), Rain: ptr(0.5), Ws10m: ptr(7.4565), Psfc: ptr(29.7699)}},
+		{apiv1.UnitsSI, apiv1.ForecastValues{Tc: ptr(304.3846), Rain: ptr(0.0127), Ws10m: ptr(3.3333333), Psfc: ptr(100812.5)}},
+		{"unknown", apiv1.ForecastValues{Tc: ptr(31.23456), Rain: ptr(12.7), Ws10m: ptr(3.3333333), Psfc: ptr(100812.5)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.system, func(t *testing.T) {
+			got := getUnitConverter(tt.system).convert(values)
+
+			for name, pair := range map[string][2]*float64{
+				"tc":    {got.Tc, tt.want.Tc},
+				"rain":  {got.Rain, tt.want.Rain},
+				"ws10m": {got.Ws10m, tt.want.Ws10m},
+				"psfc":  {got.Psfc, tt.want.Psfc},
+			} {
+				if *pair[0] != *pair[1] {
+					t.Errorf("%s = %v, want %v", name, *pair[0], *pair[1])
+				}
+			}
+			if got.TcMax != nil {
+				t.Errorf("tc_max = %v, want nil", *got.TcMax)
+			}
+		})
+	}
+}
+
+func TestDisplayMetricValuesUnrounded(t *testing.T) {
+	renderer := newForecastRenderer(forecastOutputQuery{}, "")
+	result := renderer.display([]apiv1.LocationForecast[apiv1.ForecastValues]{{
+		Forecasts: []apiv1.Forecast[apiv1.ForecastValues]{{Data: apiv1.ForecastValues{Tc: ptr(31.23456)}}},
+	}})
+
+	if tc := result[0].Forecasts[0].Data.Tc; tc == nil || *tc != "31.23456 °C" {
+		t.Fatalf("tc = %v, want 31.23456 °C", tc)
+	}
+}
@@ -1,29 +1,55 @@
 package weather
 
 import (
-	"fmt"
+	"context"
 	"time"
+
+	"github.com/olajoe/forecast_weather_api/internal/config"
+	apiv1 "github.com/olajoe/forecast_weather_api/pkg/api/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 )
 
+var tracer = otel.Tracer("github.com/olajoe/forecast_weather_api/internal/weather")
+
 type WeatherUsecase interface {
-	GetWeatherDailyByCoordinates(queries GetWeatherDailyQuery) (map[string]interface{}, error)
-	GetWeatherDailyByPlace(queries GetWeatherDailyQuery) (map[string]interface{}, error)
+	GetWeatherDailyByCoordinates(ctx context.Context, queries GetWeatherDailyQuery) ([]apiv1.LocationForecast[apiv1.ForecastValues], error)
+	GetWeatherDailyByPlace(ctx context.Context, queries GetWeatherDailyQuery) ([]apiv1.LocationForecast[apiv1.ForecastValues], error)
+	GetWeatherDailyByRegion(ctx context.Context, queries GetWeatherDailyQuery) ([]apiv1.LocationForecast[apiv1.ForecastValues], error)
+	GetWeatherHourlyByCoordinates(ctx context.Context, queries GetWeatherHourlyQuery) ([]apiv1.LocationForecast[apiv1.ForecastValues], error)
+	GetWeatherHourlyByPlace(ctx context.Context, queries GetWeatherHourlyQuery) ([]apiv1.LocationForecast[apiv1.ForecastValues], error)
+	GetWeatherDailyBatch(ctx context.Context, queries []GetWeatherDailyQuery) []WeatherDailyBatchResult
+}
+
+// WeatherDailyBatchResult is the outcome of one query of a batch.
+// Err is set instead of Result when the query failed.
+type WeatherDailyBatchResult struct {
+	Result []apiv1.LocationForecast[apiv1.ForecastValues]
+	Err    error
 }
 
 type weatherUsecase struct {
 	weatherRepository WeatherRepository
+	batchConcurrency  int
 }
 
-func NewWeatherUsecase(weatherRepository WeatherRepository) WeatherUsecase {
+func NewWeatherUsecase(weatherRepository WeatherRepository, cfg *config.Configuration) WeatherUsecase {
 	return &weatherUsecase{
 		weatherRepository: weatherRepository,
+		batchConcurrency:  max(cfg.Batch.Concurrency, 1),
 	}
 }
 
-func (u *weatherUsecase) GetWeatherDailyByCoordinates(queries GetWeatherDailyQuery) (map[string]interface{}, error) {
+func (u *weatherUsecase) GetWeatherDailyByCoordinates(ctx context.Context, queries GetWeatherDailyQuery) (_ []apiv1.LocationForecast[apiv1.ForecastValues], err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUsecase.GetWeatherDailyByCoordinates")
+	defer func() { endSpan(span, err) }()
+
 	queryParams := buildGetWeatherDailyByCoordinatesQueryParams(queries)
 
-	forecastResponse, err := u.weatherRepository.GetWeatherDailyByCoordinates(queryParams)
+	forecastResponse, err := u.weatherRepository.GetWeatherDailyByCoordinates(ctx, queryParams)
 	if err != nil {
 		return nil, err
 	}
@@ -36,10 +62,13 @@ func (u *weatherUsecase) GetWeatherDailyByCoordinates(queries GetWeatherDailyQue
 	return result, nil
 }
 
-func (u *weatherUsecase) GetWeatherDailyByPlace(queries GetWeatherDailyQuery) (map[string]interface{}, error) {
+func (u *weatherUsecase) GetWeatherDailyByPlace(ctx context.Context, queries GetWeatherDailyQuery) (_ []apiv1.LocationForecast[apiv1.ForecastValues], err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUsecase.GetWeatherDailyByPlace")
+	defer func() { endSpan(span, err) }()
+
 	queryParams := buildGetWeatherDailyByPlaceQueryParams(queries)
 
-	forecastResponse, err := u.weatherRepository.GetWeatherDailyByPlace(queryParams)
+	forecastResponse, err := u.weatherRepository.GetWeatherDailyByPlace(ctx, queryParams)
 	if err != nil {
 		return nil, err
 	}
@@ -52,161 +81,201 @@ func (u *weatherUsecase) GetWeatherDailyByPlace(queries GetWeatherDailyQuery) (m
 	return result, nil
 }
 
-func mapWeatherForecastDailyResponseToResult(response *WeatherForecastDailyResponse) (map[string]interface{}, error) {
-	var result map[string]interface{}
-
-	for _, forecast := range response.WeatherForecasts {
-		result = map[string]interface{}{
-			"location":  fulfillLocationValue(forecast.Location),
-			"forecasts": []interface{}{},
-		}
-
-		for _, forecastItem := range forecast.Forecasts {
-			tData, err := time.Parse(time.RFC3339, forecastItem.Time)
-			if err != nil {
-				return nil, err
-			}
-
-			forecastData := fulfillForecastDataValue(forecastItem.Data)
+func (u *weatherUsecase) GetWeatherDailyByRegion(ctx context.Context, queries GetWeatherDailyQuery) (_ []apiv1.LocationForecast[apiv1.ForecastValues], err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUsecase.GetWeatherDailyByRegion")
+	defer func() { endSpan(span, err) }()
 
-			result["forecasts"] = append(result["forecasts"].([]interface{}), map[string]interface{}{
-				"time": tData.Format(time.DateOnly),
-				"data": forecastData,
-			})
+	queryParams := buildGetWeatherDailyByRegionQueryParams(queries)
 
-		}
+	forecastResponse, err := u.weatherRepository.GetWeatherDailyByRegion(ctx, queryParams)
+	if err != nil {
+		return nil, err
+	}
 
+	result, err := mapWeatherForecastDailyResponseToResult(forecastResponse)
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
-func fulfillLocationValue(location Location) map[string]interface{} {
-	result := map[string]interface{}{
-		"lat": location.Lat,
-		"lon": location.Lon,
-	}
+func (u *weatherUsecase) GetWeatherHourlyByCoordinates(ctx context.Context, queries GetWeatherHourlyQuery) (_ []apiv1.LocationForecast[apiv1.ForecastValues], err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUsecase.GetWeatherHourlyByCoordinates")
+	defer func() { endSpan(span, err) }()
 
-	if location.Province != nil {
-		result["province"] = location.Lat
-	}
+	queryParams := buildGetWeatherHourlyByCoordinatesQueryParams(queries)
 
-	if location.Amphoe != nil {
-		result["amphoe"] = location.Amphoe
+	forecastResponse, err := u.weatherRepository.GetWeatherHourlyByCoordinates(ctx, queryParams)
+	if err != nil {
+		return nil, err
 	}
 
-	if location.Tambon != nil {
-		result["tambon"] = location.Tambon
+	result, err := mapWeatherForecastHourlyResponseToResult(forecastResponse)
+	if err != nil {
+		return nil, err
 	}
 
-	if location.Region != nil {
-		result["region"] = location.Region
-	}
+	return result, nil
+}
 
-	if location.Geocode != nil {
-		result["geocode"] = location.Geocode
+func (u *weatherUsecase) GetWeatherHourlyByPlace(ctx context.Context, queries GetWeatherHourlyQuery) (_ []apiv1.LocationForecast[apiv1.ForecastValues], err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUsecase.GetWeatherHourlyByPlace")
+	defer func() { endSpan(span, err) }()
+
+	queryParams := buildGetWeatherHourlyByPlaceQueryParams(queries)
+
+	forecastResponse, err := u.weatherRepository.GetWeatherHourlyByPlace(ctx, queryParams)
+	if err != nil {
+		return nil, err
 	}
 
-	if location.AreaType != nil {
-		result["areatype"] = location.AreaType
+	result, err := mapWeatherForecastHourlyResponseToResult(forecastResponse)
+	if err != nil {
+		return nil, err
 	}
 
-	return result
+	return result, nil
 }
 
-func fulfillForecastDataValue(forecastData ForecastData) map[string]interface{} {
-	result := map[string]interface{}{}
+// GetWeatherDailyBatch runs every query with at most batchConcurrency TMD calls
+// at a time and returns one result per query in the same order. A query is a
+// place query when it has a province, amphoe or tambon, otherwise coordinates.
+func (u *weatherUsecase) GetWeatherDailyBatch(ctx context.Context, queries []GetWeatherDailyQuery) []WeatherDailyBatchResult {
+	ctx, span := tracer.Start(ctx, "WeatherUsecase.GetWeatherDailyBatch", trace.WithAttributes(
+		attribute.Int("batch.size", len(queries)),
+	))
+	defer span.End()
+
+	results := make([]WeatherDailyBatchResult, len(queries))
+
+	var group errgroup.Group
+	group.SetLimit(u.batchConcurrency)
+
+	for i, query := range queries {
+		group.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+				return nil
+			}
 
-	if forecastData.TcMin != nil {
-		result["tcMin"] = fmt.Sprintf("%v °C", *forecastData.TcMin)
-	}
+			if query.isPlace() {
+				results[i].Result, results[i].Err = u.GetWeatherDailyByPlace(ctx, query)
+			} else {
+				results[i].Result, results[i].Err = u.GetWeatherDailyByCoordinates(ctx, query)
+			}
 
-	if forecastData.TcMax != nil {
-		result["tcMax"] = fmt.Sprintf("%v °C", *forecastData.TcMax)
+			return nil
+		})
 	}
+	_ = group.Wait()
 
-	if forecastData.Rh != nil {
-		result["rh"] = fmt.Sprintf("%v %%", *forecastData.Rh)
-	}
+	return results
+}
 
-	if forecastData.Slp != nil {
-		result["slp"] = fmt.Sprintf("%v hPa", *forecastData.Slp)
+// endSpan ends span and marks it failed when err is set.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
+	span.End()
+}
 
-	if forecastData.Psfc != nil {
-		result["psfc"] = fmt.Sprintf("%v Pa", *forecastData.Psfc)
+// mapWeatherForecastDailyResponseToResult returns one entry per location in
+// the same order as TMD, e.g. every tambon of a subarea place query.
+func mapWeatherForecastDailyResponseToResult(response *WeatherForecastDailyResponse) ([]apiv1.LocationForecast[apiv1.ForecastValues], error) {
+	if len(response.WeatherForecasts) == 0 {
+		return nil, ErrNotFound
 	}
 
-	if forecastData.Rain != nil {
-		result["rain"] = fmt.Sprintf("%v mm", *forecastData.Rain)
-	}
+	result := make([]apiv1.LocationForecast[apiv1.ForecastValues], 0, len(response.WeatherForecasts))
 
-	if forecastData.Ws10m != nil {
-		result["ws10m"] = fmt.Sprintf("%v m/s", *forecastData.Ws10m)
-	}
+	for _, forecast := range response.WeatherForecasts {
+		forecasts, err := mapForecastsToResult(forecast.Forecasts, time.DateOnly)
+		if err != nil {
+			return nil, err
+		}
 
-	if forecastData.Wd10m != nil {
-		result["wd10m"] = fmt.Sprintf("%v °", *forecastData.Wd10m)
+		result = append(result, apiv1.LocationForecast[apiv1.ForecastValues]{
+			Location:  fulfillLocationValue(forecast.Location),
+			Forecasts: forecasts,
+		})
 	}
 
-	if forecastData.CloudLow != nil {
-		result["cloudLow"] = fmt.Sprintf("%v %%", *forecastData.CloudLow)
-	}
+	return result, nil
+}
 
-	if forecastData.CloudMed != nil {
-		result["cloudMed"] = fmt.Sprintf("%v %%", *forecastData.CloudMed)
+// mapWeatherForecastHourlyResponseToResult returns one entry per location and
+// keeps the full timestamp of each forecast since hourly entries share the same date.
+func mapWeatherForecastHourlyResponseToResult(response *WeatherForecastHourlyResponse) ([]apiv1.LocationForecast[apiv1.ForecastValues], error) {
+	if len(response.WeatherForecasts) == 0 {
+		return nil, ErrNotFound
 	}
 
-	if forecastData.CloudHigh != nil {
-		result["cloudHigh"] = fmt.Sprintf("%v %%", *forecastData.CloudHigh)
-	}
+	result := make([]apiv1.LocationForecast[apiv1.ForecastValues], 0, len(response.WeatherForecasts))
 
-	if forecastData.Swdown != nil {
-		result["swDown"] = fmt.Sprintf("%v W/m^2", *forecastData.Swdown)
-	}
+	for _, forecast := range response.WeatherForecasts {
+		forecasts, err := mapForecastsToResult(forecast.Forecasts, time.RFC3339)
+		if err != nil {
+			return nil, err
+		}
 
-	if forecastData.Cond != nil {
-		result["cond"] = mapConditionToValue(*forecastData.Cond)
+		result = append(result, apiv1.LocationForecast[apiv1.ForecastValues]{
+			Location:  fulfillLocationValue(forecast.Location),
+			Forecasts: forecasts,
+		})
 	}
 
-	// if forecastData.Ws != nil {
-	// 	result["ws"] = forecastData.Ws
-	// }
+	return result, nil
+}
 
-	// if forecastData.Wd != nil {
-	// 	result["wd"] = forecastData.Wd
-	// }
+func mapForecastsToResult(forecasts []Forecast, timeLayout string) ([]apiv1.Forecast[apiv1.ForecastValues], error) {
+	result := make([]apiv1.Forecast[apiv1.ForecastValues], 0, len(forecasts))
 
-	return result
+	for _, forecastItem := range forecasts {
+		tData, err := time.Parse(time.RFC3339, forecastItem.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, apiv1.Forecast[apiv1.ForecastValues]{
+			Time: tData.Format(timeLayout),
+			Data: fulfillForecastDataValue(forecastItem.Data),
+		})
+	}
+
+	return result, nil
+}
+
+func fulfillLocationValue(location Location) apiv1.Location {
+	return apiv1.Location{
+		Province: location.Province,
+		Amphoe:   location.Amphoe,
+		Tambon:   location.Tambon,
+		Region:   location.Region,
+		Geocode:  location.Geocode,
+		AreaType: location.AreaType,
+		Lat:      location.Lat,
+		Lon:      location.Lon,
+	}
 }
 
-func mapConditionToValue(condition float64) string {
-	switch condition {
-	case 1:
-		return "ท้องฟ้าแจ่มใส (Clear)"
-	case 2:
-		return "มีเมฆบางส่วน (Partly cloudy)"
-	case 3:
-		return "เมฆเป็นส่วนมาก (Cloudy)"
-	case 4:
-		return "มีเมฆมาก (Overcast)"
-	case 5:
-		return "ฝนตกเล็กน้อย (Light rain)"
-	case 6:
-		return "ฝนปานกลาง (Moderate rain)"
-	case 7:
-		return "ฝนตกหนัก (Heavy rain)"
-	case 8:
-		return "ฝนฟ้าคะนอง (Thunderstorm)"
-	case 9:
-		return "อากาศหนาวจัด (Very cold)"
-	case 10:
-		return "อากาศหนาว (Cold)"
-	case 11:
-		return "อากาศเย็น (Cool)"
-	case 12:
-		return "อากาศร้อนจัด (Very hot)"
-	default:
-		return ""
+func fulfillForecastDataValue(forecastData ForecastData) apiv1.ForecastValues {
+	return apiv1.ForecastValues{
+		Tc:        forecastData.Tc,
+		TcMin:     forecastData.TcMin,
+		TcMax:     forecastData.TcMax,
+		Rh:        forecastData.Rh,
+		Slp:       forecastData.Slp,
+		Psfc:      forecastData.Psfc,
+		Rain:      forecastData.Rain,
+		Ws10m:     forecastData.Ws10m,
+		Wd10m:     forecastData.Wd10m,
+		CloudLow:  forecastData.CloudLow,
+		CloudMed:  forecastData.CloudMed,
+		CloudHigh: forecastData.CloudHigh,
+		SwDown:    forecastData.Swdown,
+		Cond:      forecastData.Cond,
 	}
 }
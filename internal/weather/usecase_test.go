@@ -0,0 +1,169 @@
+package weather
+
+import (
+	"errors"
+	"testing"
+
+	apiv1 "github.com/olajoe/forecast_weather_api/pkg/api/v1"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func forecastFixture(province string, tc float64, times ...string) ([]Forecast, Location) {
+	forecasts := make([]Forecast, 0, len(times))
+	for i, forecastTime := range times {
+		forecasts = append(forecasts, Forecast{
+			Time: forecastTime,
+			Data: ForecastData{Tc: ptr(tc + float64(i))},
+		})
+	}
+
+	return forecasts, Location{Province: ptr(province), Lat: 13.75, Lon: 100.5}
+}
+
+type expectedLocation struct {
+	province string
+	times    []string
+	tcs      []float64
+}
+
+func TestMapWeatherForecastDailyResponseToResult(t *testing.T) {
+	bangkokForecasts, bangkok := forecastFixture("Bangkok", 30, "2026-10-18T00:00:00+07:00", "2026-10-19T00:00:00+07:00")
+	chiangMaiForecasts, chiangMai := forecastFixture("Chiang Mai", 25, "2026-10-18T00:00:00+07:00")
+	phuketForecasts, phuket := forecastFixture("Phuket", 28, "2026-10-18T00:00:00+07:00", "2026-10-19T00:00:00+07:00", "2026-10-20T00:00:00+07:00")
+
+	tests := []struct {
+		name     string
+		response WeatherForecastDailyResponse
+		want     []expectedLocation
+		wantErr  error
+	}{
+		{
+			name:     "no location",
+			response: WeatherForecastDailyResponse{},
+			wantErr:  ErrNotFound,
+		},
+		{
+			name: "one location",
+			response: WeatherForecastDailyResponse{WeatherForecasts: []WeatherForecastDaily{
+				{Location: bangkok, Forecasts: bangkokForecasts},
+			}},
+			want: []expectedLocation{
+				{"Bangkok", []string{"2026-10-18", "2026-10-19"}, []float64{30, 31}},
+			},
+		},
+		{
+			name: "many locations keep TMD order",
+			response: WeatherForecastDailyResponse{WeatherForecasts: []WeatherForecastDaily{
+				{Location: phuket, Forecasts: phuketForecasts},
+				{Location: bangkok, Forecasts: bangkokForecasts},
+				{Location: chiangMai, Forecasts: chiangMaiForecasts},
+			}},
+			want: []expectedLocation{
+				{"Phuket", []string{"2026-10-18", "2026-10-19", "2026-10-20"}, []float64{28, 29, 30}},
+				{"Bangkok", []string{"2026-10-18", "2026-10-19"}, []float64{30, 31}},
+				{"Chiang Mai", []string{"2026-10-18"}, []float64{25}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := mapWeatherForecastDailyResponseToResult(&tt.response)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			assertLocations(t, result, tt.want)
+		})
+	}
+}
+
+func TestMapWeatherForecastHourlyResponseToResult(t *testing.T) {
+	bangkokForecasts, bangkok := forecastFixture("Bangkok", 30, "2026-10-18T10:00:00+07:00", "2026-10-18T11:00:00+07:00")
+	phuketForecasts, phuket := forecastFixture("Phuket", 28, "2026-10-18T10:00:00+07:00")
+
+	tests := []struct {
+		name     string
+		response WeatherForecastHourlyResponse
+		want     []expectedLocation
+		wantErr  error
+	}{
+		{
+			name:     "no location",
+			response: WeatherForecastHourlyResponse{},
+			wantErr:  ErrNotFound,
+		},
+		{
+			name: "one location keeps the hour",
+			response: WeatherForecastHourlyResponse{WeatherForecasts: []WeatherForecastHourly{
+				{Location: bangkok, Forecasts: bangkokForecasts},
+			}},
+			want: []expectedLocation{
+				{"Bangkok", []string{"2026-10-18T10:00:00+07:00", "2026-10-18T11:00:00+07:00"}, []float64{30, 31}},
+			},
+		},
+		{
+			name: "many locations keep TMD order",
+			response: WeatherForecastHourlyResponse{WeatherForecasts: []WeatherForecastHourly{
+				{Location: phuket, Forecasts: phuketForecasts},
+				{Location: bangkok, Forecasts: bangkokForecasts},
+			}},
+			want: []expectedLocation{
+				{"Phuket", []string{"2026-10-18T10:00:00+07:00"}, []float64{28}},
+				{"Bangkok", []string{"2026-10-18T10:00:00+07:00", "2026-10-18T11:00:00+07:00"}, []float64{30, 31}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := mapWeatherForecastHourlyResponseToResult(&tt.response)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			assertLocations(t, result, tt.want)
+		})
+	}
+}
+
+func TestMapForecastsToResultInvalidTime(t *testing.T) {
+	response := WeatherForecastDailyResponse{WeatherForecasts: []WeatherForecastDaily{
+		{Forecasts: []Forecast{{Time: "yesterday"}}},
+	}}
+
+	if _, err := mapWeatherForecastDailyResponseToResult(&response); err == nil {
+		t.Fatal("error = nil, want a time parse error")
+	}
+}
+
+func assertLocations(t *testing.T, result []apiv1.LocationForecast[apiv1.ForecastValues], want []expectedLocation) {
+	t.Helper()
+
+	if len(result) != len(want) {
+		t.Fatalf("locations = %d, want %d", len(result), len(want))
+	}
+
+	for i, location := range want {
+		got := result[i]
+		if got.Location.Province == nil || *got.Location.Province != location.province {
+			t.Fatalf("location %d province = %v, want %s", i, got.Location.Province, location.province)
+		}
+		if len(got.Forecasts) != len(location.times) {
+			t.Fatalf("location %d forecasts = %d, want %d", i, len(got.Forecasts), len(location.times))
+		}
+
+		for j, forecast := range got.Forecasts {
+			if forecast.Time != location.times[j] {
+				t.Errorf("location %d forecast %d time = %s, want %s", i, j, forecast.Time, location.times[j])
+			}
+
+			if tc := forecast.Data.Tc; tc == nil || *tc != location.tcs[j] {
+				t.Errorf("location %d forecast %d tc = %v, want %v", i, j, tc, location.tcs[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package v1 holds the response types of the /v1 weather endpoints so that
+// Go clients can decode responses with the same structs the server encodes.
+package v1
+
+const (
+	FormatNumeric = "numeric"
+	FormatDisplay = "display"
+)
+
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+	UnitsSI       = "si"
+)
+
+type Location struct {
+	Province *string `json:"province,omitempty"`
+	Amphoe   *string `json:"amphoe,omitempty"`
+	Tambon   *string `json:"tambon,omitempty"`
+	Region   *string `json:"region,omitempty"`
+	Geocode  *string `json:"geocode,omitempty"`
+	AreaType *string `json:"areatype,omitempty"`
+
+	Lat float32 `json:"lat"`
+	Lon float32 `json:"lon"`
+}
+
+// ForecastValues holds raw numbers, returned with format=numeric.
+// Their units are described once per response in Units.
+type ForecastValues struct {
+	Tc        *float64 `json:"tc,omitempty"`
+	TcMin     *float64 `json:"tcMin,omitempty"`
+	TcMax     *float64 `json:"tcMax,omitempty"`
+	Rh        *float64 `json:"rh,omitempty"`
+	Slp       *float64 `json:"slp,omitempty"`
+	Psfc      *float64 `json:"psfc,omitempty"`
+	Rain      *float64 `json:"rain,omitempty"`
+	Ws10m     *float64 `json:"ws10m,omitempty"`
+	Wd10m     *float64 `json:"wd10m,omitempty"`
+	CloudLow  *float64 `json:"cloudLow,omitempty"`
+	CloudMed  *float64 `json:"cloudMed,omitempty"`
+	CloudHigh *float64 `json:"cloudHigh,omitempty"`
+	SwDown    *float64 `json:"swDown,omitempty"`
+	Cond      *float64 `json:"cond,omitempty"`
+	CondText  *string  `json:"condText,omitempty"` // localized label of Cond
+}
+
+// DisplayForecastValues holds human readable values with their unit suffix,
+// returned with format=display.
+type DisplayForecastValues struct {
+	Tc        *string `json:"tc,omitempty"`
+	TcMin     *string `json:"tcMin,omitempty"`
+	TcMax     *string `json:"tcMax,omitempty"`
+	Rh        *string `json:"rh,omitempty"`
+	Slp       *string `json:"slp,omitempty"`
+	Psfc      *string `json:"psfc,omitempty"`
+	Rain      *string `json:"rain,omitempty"`
+	Ws10m     *string `json:"ws10m,omitempty"`
+	Wd10m     *string `json:"wd10m,omitempty"`
+	CloudLow  *string `json:"cloudLow,omitempty"`
+	CloudMed  *string `json:"cloudMed,omitempty"`
+	CloudHigh *string `json:"cloudHigh,omitempty"`
+	SwDown    *string `json:"swDown,omitempty"`
+	Cond      *string `json:"cond,omitempty"`     // localized condition label
+	CondCode  *int    `json:"condCode,omitempty"` // TMD condition code of Cond
+}
+
+type Values interface {
+	ForecastValues | DisplayForecastValues
+}
+
+// Units labels the unit of every numeric field in ForecastValues.
+type Units struct {
+	Tc        string `json:"tc"`
+	TcMin     string `json:"tcMin"`
+	TcMax     string `json:"tcMax"`
+	Rh        string `json:"rh"`
+	Slp       string `json:"slp"`
+	Psfc      string `json:"psfc"`
+	Rain      string `json:"rain"`
+	Ws10m     string `json:"ws10m"`
+	Wd10m     string `json:"wd10m"`
+	CloudLow  string `json:"cloudLow"`
+	CloudMed  string `json:"cloudMed"`
+	CloudHigh string `json:"cloudHigh"`
+	SwDown    string `json:"swDown"`
+}
+
+// Forecast is a single daily or hourly forecast entry.
+// Time is YYYY-MM-DD for daily forecasts and RFC3339 for hourly forecasts.
+type Forecast[V Values] struct {
+	Time string `json:"time"`
+	Data V      `json:"data"`
+}
+
+type LocationForecast[V Values] struct {
+	Location  Location      `json:"location"`
+	Forecasts []Forecast[V] `json:"forecasts"`
+}
+
+// ForecastResponse is the body of the forecast endpoints. Units is only set
+// for ForecastValues.
+type ForecastResponse[V Values] struct {
+	Units *Units                `json:"units,omitempty"`
+	Data  []LocationForecast[V] `json:"data"`
+}
+
+// Error is the body of a failed request, and the error of a failed batch item.
+type Error struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Errors  any    `json:"errors,omitempty"`
+}
+
+// BatchItem is the result of one query of a batch request.
+// Either Data or Error is set.
+type BatchItem[V Values] struct {
+	Data  []LocationForecast[V] `json:"data,omitempty"`
+	Error *Error                `json:"error,omitempty"`
+}
+
+// BatchResponse is the body of the batch endpoints. Data has one item per
+// query in request order. Units is only set for ForecastValues.
+type BatchResponse[V Values] struct {
+	Units *Units         `json:"units,omitempty"`
+	Data  []BatchItem[V] `json:"data"`
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// maxCorrelationIDLength bounds caller supplied IDs since they end up in every
+// log line and in the headers sent to TMD.
+const maxCorrelationIDLength = 128
+
+type correlationIDContextKey struct{}
+
+func NewCorrelationIDContext(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID of the request, or an
+// empty string outside of a request.
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDContextKey{}).(string)
+	return correlationID
+}
+
+// resolveCorrelationID keeps the ID sent by the caller when it is a short
+// printable token, otherwise it generates a new one.
+func resolveCorrelationID(correlationID string) string {
+	if correlationID == "" || len(correlationID) > maxCorrelationIDLength {
+		return uuid.NewString()
+	}
+
+	for _, c := range correlationID {
+		if c <= ' ' || c > '~' {
+			return uuid.NewString()
+		}
+	}
+
+	return correlationID
+}
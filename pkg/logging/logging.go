@@ -24,6 +24,7 @@ func New(
 	logger := zerolog.New(os.Stdout).
 		Level(zerolog.Level(level)).
 		With().Timestamp().Caller().Stack().Logger()
+	zerolog.DefaultContextLogger = &logger
 
 	return &logger
 }
@@ -1,29 +1,113 @@
 package logging
 
 import (
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/rs/zerolog"
 )
 
-type loggingResponseWriter struct {
+const HeaderCorrelationID = "X-Correlation-Id"
+
+// Optional fields of the access log line.
+const (
+	FieldBytes     = "bytes"
+	FieldUserAgent = "user_agent"
+	FieldClientIP  = "client_ip"
+	FieldRoute     = "route"
+	FieldHeaders   = "headers"
+)
+
+const redactedValue = "[REDACTED]"
+
+// AccessLogOptions selects the optional fields of the access log line. Failed
+// requests are always logged, successful ones with SuccessSampleRate between
+// 0 and 1. Values of RedactHeaders are never logged.
+type AccessLogOptions struct {
+	Fields            []string
+	SuccessSampleRate float64
+	RedactHeaders     []string
+}
+
+// ResponseWriter records the status code and size of a response for
+// middlewares that report on it.
+type ResponseWriter struct {
 	http.ResponseWriter
-	StatusCode int
+	StatusCode   int
+	BytesWritten int64
 }
 
-func (r *loggingResponseWriter) WriteHeader(
+// NewResponseWriter returns w itself when it is already a *ResponseWriter,
+// so that the middlewares of a request share one wrapper.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+func (r *ResponseWriter) WriteHeader(
 	statusCode int,
 ) {
 	r.ResponseWriter.WriteHeader(statusCode)
-	r.StatusCode = statusCode
+	if r.StatusCode == 0 {
+		r.StatusCode = statusCode
+	}
+}
+
+func (r *ResponseWriter) Write(
+	content []byte,
+) (int, error) {
+	if r.StatusCode == 0 {
+		r.StatusCode = http.StatusOK
+	}
+
+	n, err := r.ResponseWriter.Write(content)
+	r.BytesWritten += int64(n)
+
+	return n, err
+}
+
+// Status returns the status code of the response, 200 if the handler wrote
+// nothing.
+func (r *ResponseWriter) Status() int {
+	if r.StatusCode == 0 {
+		return http.StatusOK
+	}
+
+	return r.StatusCode
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *ResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
 }
 
 type HandlerFunc = func(http.Handler) http.Handler
 
+// NewMiddleware gives each request a child of logger carrying its correlation
+// ID, taken from X-Correlation-Id or generated, and writes an access log line
+// once the request is served. The correlation ID is echoed on the response.
+// Later middlewares add request scoped fields with Ctx(ctx).UpdateContext.
 func NewMiddleware(
 	logger *zerolog.Logger,
+	options AccessLogOptions,
 ) HandlerFunc {
+	fields := map[string]bool{}
+	for _, field := range options.Fields {
+		fields[strings.TrimSpace(field)] = true
+	}
+
+	redactHeaders := map[string]bool{}
+	for _, header := range options.RedactHeaders {
+		redactHeaders[http.CanonicalHeaderKey(strings.TrimSpace(header))] = true
+	}
+
 	return func(
 		next http.Handler,
 	) http.Handler {
@@ -31,35 +115,104 @@ func NewMiddleware(
 			w http.ResponseWriter,
 			r *http.Request,
 		) {
-			ctx := r.Context()
-			ctx = logger.WithContext(ctx)
-			lw := loggingResponseWriter{ResponseWriter: w}
-
-			method := r.Method
-			endpoint := r.URL.Path
 			startTime := time.Now()
-			traceID := r.Header.Get("x-correlation-id")
+			correlationID := resolveCorrelationID(r.Header.Get(HeaderCorrelationID))
 
-			defer func() {
-				if lw.StatusCode == 0 {
-					lw.WriteHeader(http.StatusInternalServerError)
-				}
+			r.Header.Set(HeaderCorrelationID, correlationID)
+			w.Header().Set(HeaderCorrelationID, correlationID)
 
-				log := logger.Info()
-				if lw.StatusCode >= http.StatusInternalServerError {
-					log = logger.Error()
-				}
+			// WithContext stores a copy of the logger, take it back so that the
+			// access log line has the fields added while serving the request.
+			ctx := logger.With().Str("correlationID", correlationID).Logger().WithContext(r.Context())
+			ctx = NewCorrelationIDContext(ctx, correlationID)
+			requestLogger := Ctx(ctx)
+			r = r.WithContext(ctx)
+
+			lw := NewResponseWriter(w)
+			next.ServeHTTP(lw, r)
 
-				log.
-					Str("method", method).
-					Str("path", endpoint).
-					Str("traceID", traceID).
-					Int("statusCode", lw.StatusCode).
-					Str("duration", time.Since(startTime).String()).
-					Send()
-			}()
+			statusCode := lw.Status()
+			if statusCode < http.StatusBadRequest && !sampled(options.SuccessSampleRate) {
+				return
+			}
 
-			next.ServeHTTP(&lw, r.WithContext(ctx))
+			log := requestLogger.Info()
+			switch {
+			case statusCode >= http.StatusInternalServerError:
+				log = requestLogger.Error()
+			case statusCode >= http.StatusBadRequest:
+				log = requestLogger.Warn()
+			}
+
+			log.
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Int("statusCode", statusCode).
+				Str("duration", time.Since(startTime).String())
+
+			if fields[FieldRoute] {
+				log.Str("route", RouteTemplate(r))
+			}
+			if fields[FieldBytes] {
+				log.Int64("bytesWritten", lw.BytesWritten)
+			}
+			if fields[FieldUserAgent] {
+				log.Str("userAgent", r.UserAgent())
+			}
+			if fields[FieldClientIP] {
+				log.Str("clientIP", remoteHost(r))
+				if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+					log.Str("forwardedFor", forwardedFor)
+				}
+			}
+			if fields[FieldHeaders] {
+				log.Dict("headers", headersDict(r.Header, redactHeaders))
+			}
+
+			log.Msg("log response")
 		})
 	}
 }
+
+// RouteTemplate returns the template of the matched mux route, e.g.
+// /v1/weathers/daily/place, so that logs and metrics are not keyed by raw path.
+func RouteTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "unmatched"
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "unmatched"
+	}
+
+	return template
+}
+
+func sampled(rate float64) bool {
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func headersDict(header http.Header, redactHeaders map[string]bool) *zerolog.Event {
+	dict := zerolog.Dict()
+	for name, values := range header {
+		if redactHeaders[name] {
+			dict.Str(name, redactedValue)
+			continue
+		}
+
+		dict.Str(name, strings.Join(values, ", "))
+	}
+
+	return dict
+}
@@ -0,0 +1,31 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewResponseWriterIsSharedByMiddlewares(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	outer := NewResponseWriter(rec)
+	inner := NewResponseWriter(outer)
+	if inner != outer {
+		t.Fatal("NewResponseWriter wrapped a *ResponseWriter again")
+	}
+
+	inner.WriteHeader(http.StatusTeapot)
+	_, _ = inner.Write([]byte("tea"))
+
+	if outer.Status() != http.StatusTeapot || outer.BytesWritten != 3 || rec.Code != http.StatusTeapot {
+		t.Fatalf("status = %d, bytes = %d, recorded %d, want 418 and 3 bytes", outer.Status(), outer.BytesWritten, rec.Code)
+	}
+}
+
+func TestResponseWriterStatusDefaultsToOK(t *testing.T) {
+	w := NewResponseWriter(httptest.NewRecorder())
+	if w.Status() != http.StatusOK {
+		t.Fatalf("status = %d, want 200 when nothing was written", w.Status())
+	}
+}
@@ -6,12 +6,8 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// Ctx returns the logger of the request handled with ctx, or the logger
+// created by New outside of a request.
 func Ctx(ctx context.Context) *zerolog.Logger {
-	logger := zerolog.Ctx(ctx)
-	if logger != nil {
-
-		return logger
-	}
-
-	return New(LevelDebug)
+	return zerolog.Ctx(ctx)
 }